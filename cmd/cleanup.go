@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eko/monday/pkg/forward/kubernetes"
+	"github.com/eko/monday/pkg/hostfile"
+	"github.com/eko/monday/pkg/proxy"
+	"github.com/eko/monday/pkg/state"
+	"github.com/eko/monday/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Revert changes left by a Monday session that has not been stopped properly",
+	Long: `In case Monday has been killed, hosts file entries, network interface IP addresses and Kubernetes deployments
+	updated with the proxy image could be left behind. This command reverts them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		hostfile, err := hostfile.NewClient()
+		if err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		count, err := state.Recover(context.Background(), state.GetDirectory(), &reverter{hostfile: hostfile}, ui.NewEmptyView("cleanup"))
+		if err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		if count == 0 {
+			fmt.Println("✅  Nothing to clean up")
+		}
+	},
+}
+
+// reverter reverts the side effects recorded in session journals
+type reverter struct {
+	hostfile hostfile.Hostfile
+}
+
+func (r *reverter) RemoveHost(hostname string) error {
+	return r.hostfile.RemoveHost(hostname)
+}
+
+func (r *reverter) RemoveIPAlias(iface, ip string) error {
+	return proxy.RemoveIPAlias(iface, ip)
+}
+
+func (r *reverter) RestoreDeployment(ctx context.Context, deployment state.Deployment) error {
+	return kubernetes.RestoreDeployment(ctx, deployment)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/control"
+)
+
+const (
+	daemonLogFilename   = "monday.log"
+	daemonTokenFilename = "daemon.token"
+	daemonStartTimeout  = 10 * time.Second
+
+	// daemonTokenEnv is the environment variable giving the background process the proof that guardrails
+	// of its project have been confirmed by the process which launched it
+	daemonTokenEnv = "MONDAY_DAEMON_TOKEN"
+)
+
+// startDaemon launches the given project in a background process, detached from the current terminal,
+// and waits for its control API to be available
+func startDaemon(project string) error {
+	dataPath, err := config.EnsureDataPath()
+	if err != nil {
+		return err
+	}
+
+	socketPath := control.GetSocketPath()
+
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return fmt.Errorf("A Monday instance is already running in background, use 'monday stop' to stop it first")
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("Unable to find Monday executable path: %v", err)
+	}
+
+	logPath := fmt.Sprintf("%s/%s", dataPath, daemonLogFilename)
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("Unable to open daemon log file '%s': %v", logPath, err)
+	}
+	defer logFile.Close()
+
+	// Guardrails have been confirmed in this terminal, the background process can only be given this proof once
+	token, err := newDaemonToken(dataPath, project)
+	if err != nil {
+		return fmt.Errorf("Unable to create background process token: %v", err)
+	}
+
+	cmd := exec.Command(executable, "run", project, "--daemon")
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", daemonTokenEnv, token))
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		os.Remove(fmt.Sprintf("%s/%s", dataPath, daemonTokenFilename))
+		return fmt.Errorf("Unable to start Monday in background: %v", err)
+	}
+
+	// Reap the process in case it exits before being ready
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	timeout := time.After(daemonStartTimeout)
+
+	for {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			fmt.Printf("✅  Project '%s' is running in background (pid: %d, logs: %s)\n", project, cmd.Process.Pid, logPath)
+			return nil
+		}
+
+		select {
+		case err := <-exited:
+			return fmt.Errorf("Monday background process has exited (%v), please check logs in '%s'", err, logPath)
+		case <-timeout:
+			return fmt.Errorf("Monday background process is not answering after %s, please check logs in '%s'", daemonStartTimeout, logPath)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// newDaemonToken creates the single-use token proving that guardrails of the given project have been
+// confirmed before launching it in background
+func newDaemonToken(dataPath, project string) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	token := hex.EncodeToString(random)
+
+	if err := os.WriteFile(fmt.Sprintf("%s/%s", dataPath, daemonTokenFilename), []byte(project+" "+token), 0600); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeDaemonToken indicates if the given token has been created for the given project by the process
+// which launched this one. The token is removed so that it cannot be used again.
+func consumeDaemonToken(project, token string) bool {
+	path := fmt.Sprintf("%s/%s", config.GetDataPath(), daemonTokenFilename)
+
+	content, err := os.ReadFile(path)
+	os.Remove(path)
+
+	if err != nil || token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(content, []byte(project+" "+token)) == 1
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/eko/monday/pkg/control"
+	"github.com/eko/monday/pkg/inspect"
+	"github.com/spf13/cobra"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "List the HTTP requests captured by the inspector of the project running in background",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		exchanges, err := control.NewClient(control.GetSocketPath()).Requests()
+		if err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		if len(exchanges) == 0 {
+			fmt.Println("🔎  No HTTP request has been captured yet")
+			return
+		}
+
+		for _, exchange := range exchanges {
+			printExchange(exchange)
+		}
+	},
+}
+
+var inspectExportCmd = &cobra.Command{
+	Use:   "export FILE",
+	Short: "Export the captured HTTP requests as an HTTP Archive (HAR) file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		exchanges, err := control.NewClient(control.GetSocketPath()).Requests()
+		if err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		content, err := json.MarshalIndent(inspect.NewHAR(exchanges, Version), "", "  ")
+		if err != nil {
+			fmt.Printf("❌  Unable to encode HTTP archive: %v\n", err)
+			return
+		}
+
+		if err := os.WriteFile(args[0], content, 0644); err != nil {
+			fmt.Printf("❌  Unable to write HTTP archive '%s': %v\n", args[0], err)
+			return
+		}
+
+		fmt.Printf("💾  %d captured requests have been exported to '%s'\n", len(exchanges), args[0])
+	},
+}
+
+var inspectReplayCmd = &cobra.Command{
+	Use:   "replay ID",
+	Short: "Send again a captured HTTP request to its target",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Printf("❌  Invalid request identifier '%s'\n", args[0])
+			return
+		}
+
+		exchange, err := control.NewClient(control.GetSocketPath()).Replay(id)
+		if err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		printExchange(exchange)
+	},
+}
+
+func init() {
+	inspectCmd.AddCommand(inspectExportCmd)
+	inspectCmd.AddCommand(inspectReplayCmd)
+}
+
+func printExchange(exchange *inspect.Exchange) {
+	if exchange.Error != "" {
+		fmt.Printf("#%d\t%s\t%s %s\t❌ %s\t%s\n", exchange.ID, exchange.Forward, exchange.Method, exchange.URL(), exchange.Error, exchange.Duration.Round(time.Millisecond))
+		return
+	}
+
+	fmt.Printf("#%d\t%s\t%s %s\t%d\t%s\n", exchange.ID, exchange.Forward, exchange.Method, exchange.URL(), exchange.Status, exchange.Duration.Round(time.Millisecond))
+}
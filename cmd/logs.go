@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/eko/monday/pkg/control"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs APPLICATION",
+	Short: "Display the logs of a local application (or a forwarded pod) of the project running in background",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := strconv.ParseBool(cmd.Flag("follow").Value.String())
+
+		if err := control.NewClient(control.GetSocketPath()).Logs(args[0], follow, os.Stdout); err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep streaming new log lines")
+}
@@ -3,19 +3,25 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/eko/monday/internal/runtime"
 	"github.com/eko/monday/pkg/build"
 	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/control"
 	"github.com/eko/monday/pkg/forward"
 	"github.com/eko/monday/pkg/hostfile"
+	"github.com/eko/monday/pkg/inspect"
 	"github.com/eko/monday/pkg/proxy"
 	"github.com/eko/monday/pkg/run"
 	"github.com/eko/monday/pkg/setup"
+	"github.com/eko/monday/pkg/state"
 	"github.com/eko/monday/pkg/ui"
 	"github.com/eko/monday/pkg/watch"
 	"github.com/eko/monday/pkg/write"
@@ -40,7 +46,16 @@ var (
 	runner    run.Runner
 	watcher   watch.Watcher
 
+	controlServer control.Server
+	resolver      io.Closer
+
+	// stopOnce ensures an exit signal and a stop asked through the control API do not stop twice
+	stopOnce sync.Once
+
 	uiEnabled = len(os.Getenv("MONDAY_ENABLE_UI")) > 0
+
+	// confirmGuardrail asks the user to confirm remote-forwards on protected Kubernetes contexts and namespaces
+	confirmGuardrail forward.ConfirmFunc = promptGuardrail
 )
 
 func main() {
@@ -70,10 +85,16 @@ func main() {
 	runCommand.Flags().Bool("ui", false, "Enable the terminal UI")
 	rootCmd.Flags().Bool("ui", false, "Enable the terminal UI")
 
+	rootCmd.AddCommand(cleanupCmd)
 	rootCmd.AddCommand(completionCmd)
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(inspectCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(restartCmd)
 	rootCmd.AddCommand(runCommand)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(stopCmd)
 	rootCmd.AddCommand(upgradeCmd)
 	rootCmd.AddCommand(versionCmd)
 
@@ -114,9 +135,6 @@ func selectProject(conf *config.Config) string {
 }
 
 func runProject(ctx context.Context, conf *config.Config, choice string) {
-	layout := ui.NewLayout(uiEnabled)
-	layout.Init()
-
 	// Retrieve selected project configuration by its name
 	project, err := conf.GetProjectByName(choice)
 	if err != nil {
@@ -127,22 +145,98 @@ func runProject(ctx context.Context, conf *config.Config, choice string) {
 	project.PrependApplications(conf.Applications)
 	project.PrependForwards(conf.Forwards)
 
+	// Confirmation has to be asked before the UI takes over the terminal
+	checkGuardrails(conf, project.Forwards)
+
+	// The embedded DNS server cannot fall back on the hosts file, which does not handle the wildcard
+	// hostnames allowed with it, so it has to be listening before the UI takes over the terminal
+	var dnsHosts hostfile.Hostfile
+	if conf.Resolver.GetType() == config.ResolverDNS {
+		dnsServer := hostfile.NewDNSServer(conf.Resolver.GetAddress(), conf.Resolver.GetUpstream())
+
+		if err := dnsServer.Listen(); err != nil {
+			fmt.Printf("❌  Unable to start the 'dns' resolver: %v\n", err)
+			os.Exit(1)
+		}
+
+		dnsHosts = dnsServer
+		resolver = dnsServer
+	}
+
+	// HTTP exchanges of inspected forwards are captured and shown in the inspector pane
+	inspectEnabled := false
+	for _, forward := range project.Forwards {
+		if forward.Values.Inspect {
+			inspectEnabled = true
+			break
+		}
+	}
+
+	layout := ui.NewLayout(uiEnabled)
+	layout.SetInspectorEnabled(inspectEnabled)
+	layout.Init()
+
 	// Initializes hosts file manager
-	hostfile, err := hostfile.NewClient()
+	hostfileClient, err := hostfile.NewClient()
 	if err != nil {
 		panic(err)
 	}
 
-	proxyfier = proxy.NewProxy(layout.GetProxyView(), hostfile)
+	// Revert changes left by previous sessions that have not been stopped properly,
+	// then record changes made by this one
+	stateDirectory := state.GetDirectory()
+
+	if _, err := state.Recover(ctx, stateDirectory, &reverter{hostfile: hostfileClient}, layout.GetLogsView()); err != nil {
+		layout.GetLogsView().Writef("❌  Unable to clean up stale sessions: %v\n", err)
+	}
+
+	if journal, err := state.NewJournal(stateDirectory, project.Name); err != nil {
+		layout.GetLogsView().Writef("❌  %v\n", err)
+	} else {
+		state.Current = journal
+	}
+
+	// Hostnames are written into the hosts file unless they are answered by the embedded DNS server
+	var hosts hostfile.Hostfile = hostfile.NewJournaledClient(hostfileClient)
+	if dnsHosts != nil {
+		hosts = dnsHosts
+	}
+
+	proxyServer := proxy.NewProxy(layout.GetProxyView(), hosts)
+
+	var inspector *inspect.Inspector
+	if inspectEnabled {
+		inspector = inspect.NewInspector(layout.GetInspectorView())
+		proxyServer.SetInspector(inspector)
+	}
+
+	proxyfier = proxyServer
 	setuper = setup.NewSetuper(layout.GetLogsView(), project, conf.Setup)
 	builder = build.NewBuilder(layout.GetLogsView(), project, conf.Build)
 	writer = write.NewWriter(layout.GetLogsView(), project)
 	runner = run.NewRunner(layout.GetLogsView(), proxyfier, project, conf.Run)
-	forwarder = forward.NewForwarder(layout.GetForwardsView(), proxyfier, project)
+	forwarder = forward.NewForwarder(layout.GetForwardsView(), layout.GetLogsView(), proxyfier, project)
 
 	watcher = watch.NewWatcher(setuper, builder, writer, runner, forwarder, conf.Watch, project)
 	go watcher.Watch(ctx)
 
+	// Expose the control API so this instance can be managed from another terminal
+	if _, err := config.EnsureDataPath(); err != nil {
+		layout.GetLogsView().Writef("❌  %v\n", err)
+	}
+
+	server := control.NewServer(control.GetSocketPath(), runner, project, func() {
+		stopAll(ctx)
+	})
+	if inspector != nil {
+		server.SetInspector(inspector)
+	}
+
+	controlServer = server
+	if err := controlServer.Listen(); err != nil {
+		layout.GetLogsView().Writef("❌  Control API is not available: %v\n", err)
+	}
+
 	if uiEnabled {
 		defer layout.GetGui().Close()
 
@@ -150,7 +244,21 @@ func runProject(ctx context.Context, conf *config.Config, choice string) {
 			panic(err)
 		}
 
-		layout.GetStatusView().Writef(" ⇢  %s | Commands: ←/→: select view | ↑/↓: scroll up/down | a: toggle autoscroll | f: toggle fullscreen", choice)
+		commands := "←/→: select view | ↑/↓: scroll up/down | a: toggle autoscroll | f: toggle fullscreen"
+
+		// Faults injected by the proxy can be toggled to compare with the normal behavior
+		if hasFaults(project) {
+			if err := layout.GetGui().SetKeybinding("", 'x', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+				proxyServer.ToggleFaults()
+				return nil
+			}); err != nil {
+				panic(err)
+			}
+
+			commands += " | x: toggle faults"
+		}
+
+		layout.GetStatusView().Writef(" ⇢  %s | Commands: %s", choice, commands)
 
 		if err := layout.GetGui().MainLoop(); err != nil && err != gocui.ErrQuit {
 			fmt.Println(err)
@@ -159,10 +267,47 @@ func runProject(ctx context.Context, conf *config.Config, choice string) {
 	}
 }
 
+// hasFaults indicates if the proxy injects faults into the traffic of an application or a forward of the project
+func hasFaults(project *config.Project) bool {
+	for _, application := range project.Applications {
+		if application.Faults != nil {
+			return true
+		}
+	}
+
+	for _, forward := range project.Forwards {
+		if forward.Values.Faults != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkGuardrails exits if one of the given remote-forwards is refused by guardrails
+func checkGuardrails(conf *config.Config, forwards []*config.Forward) {
+	if err := forward.CheckGuardrails(conf, forwards, confirmGuardrail); err != nil {
+		fmt.Printf("❌  %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func promptGuardrail(question string) bool {
+	prompt := promptui.Prompt{
+		Label:     question,
+		IsConfirm: true,
+	}
+
+	// Prompt returns an error when the answer is no, or when there is no terminal to ask
+	_, err := prompt.Run()
+
+	return err == nil
+}
+
 // Handle for an exit signal in order to quit application on a proper way (shutting down connections and servers).
 func handleExitSignal(ctx context.Context) {
 	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, os.Kill)
+	signal.Notify(stop, os.Interrupt, os.Kill, syscall.SIGTERM)
 
 	<-stop
 
@@ -170,14 +315,23 @@ func handleExitSignal(ctx context.Context) {
 }
 
 func stopAll(ctx context.Context) {
-	fmt.Println("\n👋  Bye, closing your local applications and remote connections now")
+	stopOnce.Do(func() {
+		fmt.Println("\n👋  Bye, closing your local applications and remote connections now")
+
+		controlServer.Close()
+		watcher.Stop()
+		forwarder.Stop(ctx)
+		proxyfier.Stop()
+		runner.Stop()
+
+		if resolver != nil {
+			resolver.Close()
+		}
 
-	watcher.Stop()
-	forwarder.Stop(ctx)
-	proxyfier.Stop()
-	runner.Stop()
+		state.Current.Close()
 
-	os.Exit(0)
+		os.Exit(0)
+	})
 }
 
 func quit(ctx context.Context) func(*gocui.Gui, *gocui.View) error {
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/eko/monday/pkg/control"
+	"github.com/spf13/cobra"
+)
+
+var restartCmd = &cobra.Command{
+	Use:   "restart APPLICATION",
+	Short: "Restart a local application of the project running in background",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := control.NewClient(control.GetSocketPath()).Restart(args[0]); err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		fmt.Printf("🔁  Local application '%s' is restarting\n", args[0])
+	},
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"strconv"
 
 	"github.com/eko/monday/pkg/config"
@@ -10,7 +11,7 @@ import (
 )
 
 func runCmd(ctx context.Context) *cobra.Command {
-	return &cobra.Command{
+	command := &cobra.Command{
 		Use:   "run",
 		Short: "This command allows you to run a specific project directly",
 		Long: `In case you already have the project name you want to launch, you can launch it directly by using the run command
@@ -33,8 +34,42 @@ func runCmd(ctx context.Context) *cobra.Command {
 				choice = selectProject(conf)
 			}
 
+			if detach, _ := strconv.ParseBool(cmd.Flag("detach").Value.String()); detach {
+				// Background process has no terminal to ask confirmations, so guardrails are checked now
+				project, err := conf.GetProjectByName(choice)
+				if err != nil {
+					fmt.Printf("❌  %v\n", err)
+					return
+				}
+
+				checkGuardrails(conf, append(append([]*config.Forward{}, conf.Forwards...), project.Forwards...))
+
+				if err := startDaemon(choice); err != nil {
+					fmt.Printf("❌  %v\n", err)
+				}
+				return
+			}
+
+			// Running as a daemon: there is no terminal to display the UI nor to ask confirmations
+			if daemon, _ := strconv.ParseBool(cmd.Flag("daemon").Value.String()); daemon {
+				uiEnabled = false
+
+				// Guardrails have been confirmed only if the background process has been launched by --detach,
+				// otherwise remote-forwards needing a confirmation are refused
+				confirmed := consumeDaemonToken(choice, os.Getenv(daemonTokenEnv))
+				os.Unsetenv(daemonTokenEnv)
+
+				confirmGuardrail = func(string) bool { return confirmed }
+			}
+
 			runProject(ctx, conf, choice)
 			handleExitSignal(ctx)
 		},
 	}
+
+	command.Flags().BoolP("detach", "d", false, "Run the project in background, use 'status', 'stop', 'restart' and 'logs' commands to control it")
+	command.Flags().Bool("daemon", false, "Internal flag used when running the project in background")
+	command.Flags().MarkHidden("daemon")
+
+	return command
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eko/monday/pkg/control"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Display the status of the project running in background",
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := control.NewClient(control.GetSocketPath()).Status()
+		if err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		fmt.Printf("🖥  Project '%s' is running (pid: %d)\n", status.Project, status.PID)
+
+		if len(status.Applications) > 0 {
+			fmt.Println("\nLocal applications:")
+			for _, application := range status.Applications {
+				fmt.Printf("  %-30s %-20s exit code: %-5d restarts: %d\n", application.Name, application.State, application.ExitCode, application.Restarts)
+			}
+		}
+
+		if len(status.Forwards) > 0 {
+			fmt.Printf("\nForwards:\n  %s\n", strings.Join(status.Forwards, "\n  "))
+		}
+	},
+}
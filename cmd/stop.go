@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/eko/monday/pkg/control"
+	"github.com/spf13/cobra"
+)
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the project running in background",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := control.NewClient(control.GetSocketPath()).Stop(); err != nil {
+			fmt.Printf("❌  %v\n", err)
+			return
+		}
+
+		fmt.Println("👋  Bye, closing your local applications and remote connections now")
+	},
+}
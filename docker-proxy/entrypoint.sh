@@ -0,0 +1,21 @@
+#!/bin/sh
+set -e
+
+# Only the public key of the monday session having deployed the proxy is allowed to connect
+if [ -z "${MONDAY_AUTHORIZED_KEY}" ]; then
+    echo "MONDAY_AUTHORIZED_KEY environment variable is required" >&2
+    exit 1
+fi
+
+mkdir -p /root/.ssh
+chmod 700 /root/.ssh
+echo "${MONDAY_AUTHORIZED_KEY}" > /root/.ssh/authorized_keys
+chmod 600 /root/.ssh/authorized_keys
+
+# In sidecar interception mode, redirect the traffic received by the pod on the intercepted ports
+# to the ports listened by the SSH remote-forwards (MONDAY_REDIRECT_PORTS="<port>:<sidecar port>,...")
+for redirect in $(echo "${MONDAY_REDIRECT_PORTS}" | tr ',' ' '); do
+    iptables -t nat -A PREROUTING -p tcp --dport "${redirect%%:*}" -j REDIRECT --to-ports "${redirect##*:}"
+done
+
+exec /usr/sbin/sshd -D
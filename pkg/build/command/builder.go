@@ -38,6 +38,11 @@ func Build(application *config.Application, view ui.View, conf *config.GlobalBui
 		envs = helper.MergeMapString(build.Env, conf.Env)
 	}
 
+	// Values read from the cluster come first so that local ones override them
+	if err := helper.AddEnvVariablesFromCluster(cmd, build.EnvFrom); err != nil {
+		return err
+	}
+
 	helper.AddEnvVariables(cmd, envs)
 	if err := helper.AddEnvVariablesFromFile(cmd, build.GetEnvFile()); err != nil {
 		return err
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dependencyUnvisited = iota
+	dependencyVisiting
+	dependencyVisited
+)
+
+// SortApplicationsByDependencies returns the given applications sorted in a topological order
+// so that each application comes after the ones it depends on. Applications without any
+// dependency relationship keep their configuration order.
+func SortApplicationsByDependencies(applications []*Application) ([]*Application, error) {
+	byName := make(map[string]*Application, len(applications))
+	for _, application := range applications {
+		byName[application.Name] = application
+	}
+
+	states := make(map[string]int, len(applications))
+	sorted := make([]*Application, 0, len(applications))
+
+	var visit func(application *Application, path []string) error
+	visit = func(application *Application, path []string) error {
+		path = append(path, application.Name)
+
+		switch states[application.Name] {
+		case dependencyVisited:
+			return nil
+		case dependencyVisiting:
+			return fmt.Errorf("Dependency cycle detected between local applications: %s", strings.Join(path, " -> "))
+		}
+
+		states[application.Name] = dependencyVisiting
+
+		for _, name := range application.DependsOn {
+			dependency, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("Local application '%s' depends on unknown application '%s'", application.Name, name)
+			}
+
+			if err := visit(dependency, path); err != nil {
+				return err
+			}
+		}
+
+		states[application.Name] = dependencyVisited
+		sorted = append(sorted, application)
+
+		return nil
+	}
+
+	for _, application := range applications {
+		if err := visit(application, []string{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// checkDependencies ensures that every project (including global applications) declares
+// resolvable dependencies without any cycle
+func (c *Config) checkDependencies() error {
+	for _, project := range c.Projects {
+		applications := make([]*Application, 0, len(c.Applications)+len(project.Applications))
+		applications = append(applications, c.Applications...)
+		applications = append(applications, project.Applications...)
+
+		if _, err := SortApplicationsByDependencies(applications); err != nil {
+			return fmt.Errorf("Invalid configuration for project '%s': %v", project.Name, err)
+		}
+	}
+
+	return nil
+}
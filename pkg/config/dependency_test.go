@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortApplicationsByDependencies(t *testing.T) {
+	// Given
+	api := &Application{Name: "api", DependsOn: []string{"postgres", "grpc-backend"}}
+	grpcBackend := &Application{Name: "grpc-backend", DependsOn: []string{"postgres"}}
+	postgres := &Application{Name: "postgres"}
+	frontend := &Application{Name: "frontend"}
+
+	// When
+	applications, err := SortApplicationsByDependencies([]*Application{api, frontend, grpcBackend, postgres})
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, []*Application{postgres, grpcBackend, api, frontend}, applications)
+}
+
+func TestSortApplicationsByDependenciesWhenCycle(t *testing.T) {
+	// Given
+	applications := []*Application{
+		{Name: "api", DependsOn: []string{"grpc-backend"}},
+		{Name: "grpc-backend", DependsOn: []string{"postgres"}},
+		{Name: "postgres", DependsOn: []string{"api"}},
+	}
+
+	// When
+	sorted, err := SortApplicationsByDependencies(applications)
+
+	// Then
+	assert.Nil(t, sorted)
+	assert.EqualError(t, err, "Dependency cycle detected between local applications: api -> grpc-backend -> postgres -> api")
+}
+
+func TestSortApplicationsByDependenciesWhenUnknown(t *testing.T) {
+	// Given
+	applications := []*Application{
+		{Name: "api", DependsOn: []string{"postgres"}},
+	}
+
+	// When
+	sorted, err := SortApplicationsByDependencies(applications)
+
+	// Then
+	assert.Nil(t, sorted)
+	assert.EqualError(t, err, "Local application 'api' depends on unknown application 'postgres'")
+}
+
+func TestCheckDependenciesWithGlobalApplications(t *testing.T) {
+	// Given
+	conf := &Config{
+		Applications: []*Application{
+			{Name: "postgres", DependsOn: []string{"api"}},
+		},
+		Projects: []*Project{
+			{
+				Name: "backend",
+				Applications: []*Application{
+					{Name: "api", DependsOn: []string{"postgres"}},
+				},
+			},
+		},
+	}
+
+	// When
+	err := conf.checkDependencies()
+
+	// Then
+	assert.EqualError(t, err, "Invalid configuration for project 'backend': Dependency cycle detected between local applications: postgres -> api -> postgres")
+}
@@ -3,7 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
+	"regexp"
 	"strings"
+	"syscall"
+	"time"
 )
 
 const (
@@ -12,6 +16,35 @@ const (
 	ForwarderProxy            = "proxy"
 	ForwarderSSH              = "ssh"
 	ForwarderSSHRemote        = "ssh-remote"
+
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+
+	// DefaultStopSignal is the signal sent to local applications to stop them
+	DefaultStopSignal = "SIGTERM"
+	// DefaultStopTimeout is the duration given to local applications to stop before being killed
+	DefaultStopTimeout = 10 * time.Second
+
+	GuardrailRefuse  = "refuse"
+	GuardrailConfirm = "confirm"
+
+	EnvFromConfigMap = "configmap"
+	EnvFromSecret    = "secret"
+
+	ResolverHostfile = "hostfile"
+	ResolverDNS      = "dns"
+
+	// DefaultResolverAddress is the address the embedded DNS server listens on
+	DefaultResolverAddress = "127.0.0.1:53"
+
+	// ProxyModeTCP gives each hostname its own loopback IP address, for any TCP traffic
+	ProxyModeTCP = "tcp"
+	// ProxyModeHTTP shares the local ports between hostnames and routes HTTP (and TLS) traffic by hostname
+	ProxyModeHTTP = "http"
+
+	// DefaultFaultsErrorStatus is the status of the HTTP errors injected by the proxy
+	DefaultFaultsErrorStatus = 503
 )
 
 var (
@@ -24,6 +57,48 @@ var (
 		ForwarderSSHRemote:        true,
 	}
 
+	// AvailableRestartPolicies lists all the restart policies of local applications
+	AvailableRestartPolicies = map[string]bool{
+		RestartNever:     true,
+		RestartOnFailure: true,
+		RestartAlways:    true,
+	}
+
+	// AvailableGuardrailActions lists all the actions taken when a remote-forward matches a guardrail
+	AvailableGuardrailActions = map[string]bool{
+		GuardrailRefuse:  true,
+		GuardrailConfirm: true,
+	}
+
+	// AvailableEnvFromKinds lists all the kinds of Kubernetes resources environment variables can be read from
+	AvailableEnvFromKinds = map[string]bool{
+		EnvFromConfigMap: true,
+		EnvFromSecret:    true,
+	}
+
+	// AvailableResolvers lists all the ways hostnames are resolved to the proxy
+	AvailableResolvers = map[string]bool{
+		ResolverHostfile: true,
+		ResolverDNS:      true,
+	}
+
+	// AvailableProxyModes lists all the ways forwards can be proxified
+	AvailableProxyModes = map[string]bool{
+		ProxyModeTCP:  true,
+		ProxyModeHTTP: true,
+	}
+
+	// AvailableStopSignals lists all the signals that can be sent to stop local applications
+	AvailableStopSignals = map[string]syscall.Signal{
+		"SIGHUP":  syscall.SIGHUP,
+		"SIGINT":  syscall.SIGINT,
+		"SIGQUIT": syscall.SIGQUIT,
+		"SIGKILL": syscall.SIGKILL,
+		"SIGUSR1": syscall.SIGUSR1,
+		"SIGUSR2": syscall.SIGUSR2,
+		"SIGTERM": syscall.SIGTERM,
+	}
+
 	// ProxifiedForwarders lists all forwarders that will use the proxy
 	ProxifiedForwarders = map[string]bool{
 		ForwarderKubernetes:       true,
@@ -49,10 +124,167 @@ type Config struct {
 	GoPath     string `yaml:"gopath"`
 	KubeConfig string `yaml:"kubeconfig"`
 
+	// How hostnames are resolved: by writing them into the hosts file (default) or with an embedded DNS server
+	Resolver *Resolver `yaml:"resolver"`
+
+	// Kubernetes contexts and namespaces protected from remote-forwards
+	Guardrails []*Guardrail `yaml:"guardrails"`
+
 	// Projects
 	Projects []*Project `yaml:"projects"`
 }
 
+// Resolver represents how hostnames of applications and forwards are resolved
+type Resolver struct {
+	Type     string `yaml:"type"`
+	Address  string `yaml:"address"`
+	Upstream string `yaml:"upstream"`
+}
+
+// GetType returns the way hostnames are resolved, defaults to the hosts file
+func (r *Resolver) GetType() string {
+	if r == nil || r.Type == "" {
+		return ResolverHostfile
+	}
+
+	return r.Type
+}
+
+// GetAddress returns the address the embedded DNS server listens on
+func (r *Resolver) GetAddress() string {
+	if r == nil || r.Address == "" {
+		return DefaultResolverAddress
+	}
+
+	return r.Address
+}
+
+// GetUpstream returns the resolver unknown names are sent to, empty to use the system one
+func (r *Resolver) GetUpstream() string {
+	if r == nil {
+		return ""
+	}
+
+	return r.Upstream
+}
+
+// Faults represents the faults injected by the proxy into the traffic of a forward (or a local application)
+// in order to test timeouts and retries
+type Faults struct {
+	Latency     time.Duration `yaml:"latency"`
+	Jitter      time.Duration `yaml:"jitter"`
+	ResetRate   float64       `yaml:"reset_rate"`
+	Bandwidth   int64         `yaml:"bandwidth"`
+	ErrorRate   float64       `yaml:"error_rate"`
+	ErrorStatus int           `yaml:"error_status"`
+}
+
+// GetBandwidth returns the maximum number of bytes transferred per second in each direction, 0 if unlimited
+func (f *Faults) GetBandwidth() int64 {
+	return f.Bandwidth * 1024
+}
+
+// GetErrorStatus returns the status of the injected HTTP errors, defaults to 503
+func (f *Faults) GetErrorStatus() int {
+	if f.ErrorStatus == 0 {
+		return DefaultFaultsErrorStatus
+	}
+
+	return f.ErrorStatus
+}
+
+// Guardrail protects the Kubernetes contexts and namespaces matching its patterns (like "*prod*")
+// from remote-forwards, which update workloads of the cluster. An empty pattern matches everything.
+type Guardrail struct {
+	Context   string `yaml:"context"`
+	Namespace string `yaml:"namespace"`
+	Action    string `yaml:"action"`
+}
+
+// GetAction returns the action taken when a remote-forward matches the guardrail, defaults to refuse
+func (g *Guardrail) GetAction() string {
+	if g.Action == "" {
+		return GuardrailRefuse
+	}
+
+	return g.Action
+}
+
+// Matches indicates if the given context and namespace are protected by the guardrail
+func (g *Guardrail) Matches(context, namespace string) bool {
+	return matchPattern(g.Context, context) && matchPattern(g.Namespace, namespace)
+}
+
+func matchPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	expression, err := compilePattern(pattern)
+
+	return err == nil && expression.MatchString(value)
+}
+
+// compilePattern returns the regular expression of the given glob pattern. Unlike path.Match, '*' also
+// matches slashes so that "*prod*" matches EKS contexts like "arn:aws:eks:eu-west-1:123:cluster/prod".
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	var expression strings.Builder
+	expression.WriteString("^")
+
+	for index := 0; index < len(pattern); index++ {
+		switch character := pattern[index]; character {
+		case '*':
+			expression.WriteString(".*")
+
+		case '?':
+			expression.WriteString(".")
+
+		case '\\':
+			if index++; index >= len(pattern) {
+				return nil, path.ErrBadPattern
+			}
+			expression.WriteString(regexp.QuoteMeta(pattern[index : index+1]))
+
+		case '[':
+			end := strings.IndexByte(pattern[index+1:], ']')
+			if end < 1 {
+				return nil, path.ErrBadPattern
+			}
+
+			class := pattern[index+1 : index+1+end]
+			if strings.HasPrefix(class, "^") || strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			expression.WriteString("[" + class + "]")
+			index += end + 1
+
+		default:
+			expression.WriteString(regexp.QuoteMeta(pattern[index : index+1]))
+		}
+	}
+
+	expression.WriteString("$")
+
+	compiled, err := regexp.Compile(expression.String())
+	if err != nil {
+		return nil, path.ErrBadPattern
+	}
+
+	return compiled, nil
+}
+
+// GetGuardrail returns the first guardrail protecting the given context and namespace, nil if there is none
+func (c *Config) GetGuardrail(context, namespace string) *Guardrail {
+	for _, guardrail := range c.Guardrails {
+		if guardrail.Matches(context, namespace) {
+			return guardrail
+		}
+	}
+
+	return nil
+}
+
 // GlobalBuild represents the global configuration values for the file builder component
 type GlobalBuild struct {
 	Env map[string]string `yaml:"env"`
@@ -95,11 +327,16 @@ type Application struct {
 	Name       string      `yaml:"name"`
 	Path       string      `yaml:"path"`
 	Hostname   string      `yaml:"hostname"`
+	TLS        bool        `yaml:"tls"`
+	Port       string      `yaml:"port"`
 	Watch      bool        `yaml:"watch"`
+	DependsOn  []string    `yaml:"depends_on"`
+	Readiness  *Readiness  `yaml:"readiness"`
 	Setup      *Setup      `yaml:"setup"`
 	Build      *Build      `yaml:"build"`
 	Run        *Run        `yaml:"run"`
 	Files      []*File     `yaml:"files"`
+	Faults     *Faults     `yaml:"faults"`
 	Monitoring *Monitoring `yaml:"monitoring"`
 }
 
@@ -110,6 +347,7 @@ type Build struct {
 	Commands []string          `yaml:"commands"`
 	Env      map[string]string `yaml:"env"`
 	EnvFile  string            `yaml:"env_file"`
+	EnvFrom  []*EnvFrom        `yaml:"env_from"`
 }
 
 // GetEnvFile returns the filename guessed with current application environment
@@ -165,17 +403,43 @@ func (f *Forward) IsProxified() bool {
 	return false
 }
 
+// GetProxyMode returns the way the forward is proxified, defaults to tcp
+func (f *Forward) GetProxyMode() string {
+	if f.Values.ProxyMode == "" {
+		return ProxyModeTCP
+	}
+
+	return f.Values.ProxyMode
+}
+
 // ForwardValues represents the available values for each forward type
 type ForwardValues struct {
 	Context         string            `yaml:"context"`
 	Namespace       string            `yaml:"namespace"`
 	Labels          map[string]string `yaml:"labels"`
+	Target          string            `yaml:"target"`
 	ForwardHostname string            `yaml:"forward_hostname"`
 	Hostname        string            `yaml:"hostname"`
 	ProxyHostname   string            `yaml:"proxy_hostname"`
 	DisableProxy    bool              `yaml:"disable_proxy"`
+	ProxyMode       string            `yaml:"proxy_mode"`
+	TLS             bool              `yaml:"tls"`
+	Inspect         bool              `yaml:"inspect"`
+	Faults          *Faults           `yaml:"faults"`
+	Transport       string            `yaml:"transport"`
+	Interception    string            `yaml:"interception"`
+	Container       string            `yaml:"container"`
+	AllMatching     bool              `yaml:"all_matching"`
+	Logs            bool              `yaml:"logs"`
+	LogsContainer   string            `yaml:"logs_container"`
+	LogsTail        int64             `yaml:"logs_tail"`
 	Ports           []string          `yaml:"ports"`
 	Remote          string            `yaml:"remote"`
+	User            string            `yaml:"user"`
+	Port            string            `yaml:"port"`
+	IdentityFile    string            `yaml:"identity_file"`
+	KnownHosts      string            `yaml:"known_hosts"`
+	JumpHosts       []string          `yaml:"jump_hosts"`
 	Args            []string          `yaml:"args"`
 }
 
@@ -185,7 +449,62 @@ type Run struct {
 	Command      string            `yaml:"command"`
 	Env          map[string]string `yaml:"env"`
 	EnvFile      string            `yaml:"env_file"`
+	EnvFrom      []*EnvFrom        `yaml:"env_from"`
 	StopCommands []string          `yaml:"stop_commands"`
+	StopSignal   string            `yaml:"stop_signal"`
+	StopTimeout  time.Duration     `yaml:"stop_timeout"`
+	Restart      string            `yaml:"restart"`
+	MaxRetries   int               `yaml:"max_retries"`
+}
+
+// GetStopSignal returns the signal to send to stop the application, defaults to SIGTERM.
+// Signal names can be given with or without the "SIG" prefix.
+func (r *Run) GetStopSignal() (syscall.Signal, error) {
+	name := strings.ToUpper(r.StopSignal)
+	if name == "" {
+		name = DefaultStopSignal
+	}
+
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	signal, ok := AvailableStopSignals[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown stop signal '%s'", r.StopSignal)
+	}
+
+	return signal, nil
+}
+
+// GetStopTimeout returns the duration to wait for the application to stop before killing it
+func (r *Run) GetStopTimeout() time.Duration {
+	if r.StopTimeout <= 0 {
+		return DefaultStopTimeout
+	}
+
+	return r.StopTimeout
+}
+
+// GetRestartPolicy returns the restart policy of the application, defaults to never
+func (r *Run) GetRestartPolicy() string {
+	if r.Restart == "" {
+		return RestartNever
+	}
+
+	return r.Restart
+}
+
+// ShouldRestart indicates if the application has to be restarted after exiting with the given code
+func (r *Run) ShouldRestart(exitCode int) bool {
+	switch r.GetRestartPolicy() {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitCode != 0
+	}
+
+	return false
 }
 
 // GetEnvFile returns the filename guessed with current application environment
@@ -197,11 +516,51 @@ func (r *Run) GetEnvFile() string {
 	return getValueByExecutionContext(r.EnvFile)
 }
 
+// Readiness represents the probes that have to succeed to consider a local application as ready
+type Readiness struct {
+	TCP     *TCPProbe     `yaml:"tcp"`
+	HTTP    *HTTPProbe    `yaml:"http"`
+	Log     *LogProbe     `yaml:"log"`
+	Command *CommandProbe `yaml:"command"`
+}
+
+// ProbeSettings represents the settings shared by all readiness probes
+type ProbeSettings struct {
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// TCPProbe represents a readiness probe dialing a TCP address
+type TCPProbe struct {
+	ProbeSettings `yaml:",inline"`
+	Address       string `yaml:"address"`
+}
+
+// HTTPProbe represents a readiness probe sending a HTTP GET request
+type HTTPProbe struct {
+	ProbeSettings `yaml:",inline"`
+	URL           string `yaml:"url"`
+	Status        int    `yaml:"status"`
+}
+
+// LogProbe represents a readiness probe matching a pattern on the application stdout/stderr
+type LogProbe struct {
+	ProbeSettings `yaml:",inline"`
+	Pattern       string `yaml:"pattern"`
+}
+
+// CommandProbe represents a readiness probe running a command that has to exit successfully
+type CommandProbe struct {
+	ProbeSettings `yaml:",inline"`
+	Command       string `yaml:"command"`
+}
+
 // Setup represents application setup information
 type Setup struct {
 	Commands []string          `yaml:"commands"`
 	Env      map[string]string `yaml:"env"`
 	EnvFile  string            `yaml:"env_file"`
+	EnvFrom  []*EnvFrom        `yaml:"env_from"`
 }
 
 // GetEnvFile returns the filename guessed with current application environment
@@ -213,6 +572,16 @@ func (s *Setup) GetEnvFile() string {
 	return getValueByExecutionContext(s.EnvFile)
 }
 
+// EnvFrom references a Kubernetes ConfigMap or Secret whose values are given as environment variables
+// to local applications. Only the given keys are used when specified.
+type EnvFrom struct {
+	Kind      string   `yaml:"kind"`
+	Context   string   `yaml:"context"`
+	Namespace string   `yaml:"namespace"`
+	Name      string   `yaml:"name"`
+	Keys      []string `yaml:"keys"`
+}
+
 // Monitoring represents application monitoring information
 type Monitoring struct {
 	Port string `yaml:"port"`
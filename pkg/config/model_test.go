@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -144,3 +146,120 @@ func TestProjectPrependForwards(t *testing.T) {
 		{Name: "My project forward 2"},
 	}, project.Forwards)
 }
+
+func TestRunShouldRestart(t *testing.T) {
+	// Given
+	testCases := []struct {
+		restart  string
+		exitCode int
+		expected bool
+	}{
+		{restart: "", exitCode: 1, expected: false},
+		{restart: RestartNever, exitCode: 1, expected: false},
+		{restart: RestartOnFailure, exitCode: 0, expected: false},
+		{restart: RestartOnFailure, exitCode: 1, expected: true},
+		{restart: RestartOnFailure, exitCode: -1, expected: true},
+		{restart: RestartAlways, exitCode: 0, expected: true},
+		{restart: RestartAlways, exitCode: 1, expected: true},
+	}
+
+	// When - Then
+	for _, testCase := range testCases {
+		run := Run{Restart: testCase.restart}
+
+		assert.Equal(t, testCase.expected, run.ShouldRestart(testCase.exitCode))
+	}
+}
+
+func TestRunGetStopSignal(t *testing.T) {
+	// Given
+	testCases := []struct {
+		stopSignal string
+		expected   syscall.Signal
+	}{
+		{stopSignal: "", expected: syscall.SIGTERM},
+		{stopSignal: "SIGINT", expected: syscall.SIGINT},
+		{stopSignal: "quit", expected: syscall.SIGQUIT},
+	}
+
+	// When - Then
+	for _, testCase := range testCases {
+		run := Run{StopSignal: testCase.stopSignal}
+
+		signal, err := run.GetStopSignal()
+
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expected, signal)
+	}
+}
+
+func TestRunGetStopTimeout(t *testing.T) {
+	// Given
+	run := Run{}
+	customRun := Run{StopTimeout: 3 * time.Second}
+
+	// When - Then
+	assert.Equal(t, DefaultStopTimeout, run.GetStopTimeout())
+	assert.Equal(t, 3*time.Second, customRun.GetStopTimeout())
+}
+
+func TestConfigGetGuardrail(t *testing.T) {
+	// Given
+	conf := &Config{
+		Guardrails: []*Guardrail{
+			{Context: "*prod*", Action: GuardrailRefuse},
+			{Context: "staging", Namespace: "payment-*", Action: GuardrailConfirm},
+		},
+	}
+
+	testCases := []struct {
+		context   string
+		namespace string
+		expected  *Guardrail
+	}{
+		{context: "eu-prod-1", namespace: "backend", expected: conf.Guardrails[0]},
+		{context: "arn:aws:eks:eu-west-1:123:cluster/prod", namespace: "backend", expected: conf.Guardrails[0]},
+		{context: "gke_acme_europe-west1_prod/cluster", namespace: "backend", expected: conf.Guardrails[0]},
+		{context: "staging", namespace: "payment-api", expected: conf.Guardrails[1]},
+		{context: "staging", namespace: "backend", expected: nil},
+		{context: "dev", namespace: "payment-api", expected: nil},
+	}
+
+	// When - Then
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, conf.GetGuardrail(testCase.context, testCase.namespace))
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{pattern: "", value: "anything", expected: true},
+		{pattern: "*prod*", value: "arn:aws:eks:eu-west-1:123:cluster/prod", expected: true},
+		{pattern: "*prod*", value: "staging", expected: false},
+		{pattern: "payment-?", value: "payment-1", expected: true},
+		{pattern: "payment-?", value: "payment-12", expected: false},
+		{pattern: "[ps]rod", value: "srod", expected: true},
+		{pattern: "[!ps]rod", value: "prod", expected: false},
+		{pattern: "eu.prod", value: "eu-prod", expected: false},
+		{pattern: "\\*prod", value: "*prod", expected: true},
+	}
+
+	for _, testCase := range testCases {
+		// When - Then
+		assert.Equal(t, testCase.expected, matchPattern(testCase.pattern, testCase.value), "%s matching %s", testCase.pattern, testCase.value)
+	}
+}
+
+func TestGuardrailGetAction(t *testing.T) {
+	// Given
+	guardrail := Guardrail{Context: "*prod*"}
+	confirmGuardrail := Guardrail{Context: "*prod*", Action: GuardrailConfirm}
+
+	// When - Then
+	assert.Equal(t, GuardrailRefuse, guardrail.GetAction())
+	assert.Equal(t, GuardrailConfirm, confirmGuardrail.GetAction())
+}
@@ -21,6 +21,7 @@ const (
 
 var (
 	defaultConfigPath = os.Getenv("HOME")
+	defaultDataPath   = fmt.Sprintf("%s/.monday", os.Getenv("HOME"))
 
 	// Filepath is the path of the YAML configuration files when you
 	// just have a single config file
@@ -64,6 +65,41 @@ func Load() (*Config, error) {
 		panic(fmt.Sprintf("An error has occured while reading configuration file:\n%v", err))
 	}
 
+	// Ensure local applications dependencies can be resolved
+	if err := conf.checkDependencies(); err != nil {
+		return nil, err
+	}
+
+	// Ensure local applications run settings are valid
+	if err := conf.checkRunSettings(); err != nil {
+		return nil, err
+	}
+
+	// Ensure ConfigMaps and Secrets referenced by local applications are valid
+	if err := conf.checkEnvFromSettings(); err != nil {
+		return nil, err
+	}
+
+	// Ensure local applications served over TLS can be reached
+	if err := conf.checkTLSSettings(); err != nil {
+		return nil, err
+	}
+
+	// Ensure faults injected by the proxy are valid
+	if err := conf.checkFaultsSettings(); err != nil {
+		return nil, err
+	}
+
+	// Ensure hostnames can be resolved
+	if err := conf.checkResolver(); err != nil {
+		return nil, err
+	}
+
+	// Ensure guardrails are valid, a protected cluster must not be used because of a typo
+	if err := conf.checkGuardrails(); err != nil {
+		return nil, err
+	}
+
 	// Override GOPATH environment variable if defined in configuration
 	if conf.GoPath != "" {
 		os.Setenv("GOPATH", conf.GoPath)
@@ -147,6 +183,185 @@ func (c *Config) GetProjectByName(name string) (*Project, error) {
 	return nil, fmt.Errorf("Unable to find project name '%s' in the configuration", name)
 }
 
+func (c *Config) checkRunSettings() error {
+	applications := append([]*Application{}, c.Applications...)
+	for _, project := range c.Projects {
+		applications = append(applications, project.Applications...)
+	}
+
+	for _, application := range applications {
+		if application.Run == nil {
+			continue
+		}
+
+		if result, ok := AvailableRestartPolicies[application.Run.GetRestartPolicy()]; !ok || !result {
+			return fmt.Errorf("The '%s' restart policy of local application '%s' is not managed, please use one of: never, on-failure, always", application.Run.Restart, application.Name)
+		}
+
+		if _, err := application.Run.GetStopSignal(); err != nil {
+			return fmt.Errorf("Invalid run section of local application '%s': %v", application.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) checkEnvFromSettings() error {
+	applications := append([]*Application{}, c.Applications...)
+	for _, project := range c.Projects {
+		applications = append(applications, project.Applications...)
+	}
+
+	for _, application := range applications {
+		envFrom := make([]*EnvFrom, 0)
+		if application.Setup != nil {
+			envFrom = append(envFrom, application.Setup.EnvFrom...)
+		}
+		if application.Build != nil {
+			envFrom = append(envFrom, application.Build.EnvFrom...)
+		}
+		if application.Run != nil {
+			envFrom = append(envFrom, application.Run.EnvFrom...)
+		}
+
+		for _, source := range envFrom {
+			if result, ok := AvailableEnvFromKinds[source.Kind]; !ok || !result {
+				return fmt.Errorf("The '%s' env_from kind of local application '%s' is not managed, please use one of: configmap, secret", source.Kind, application.Name)
+			}
+
+			if source.Name == "" {
+				return fmt.Errorf("Please provide the name of the %s to read environment variables from in local application '%s'", source.Kind, application.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) checkTLSSettings() error {
+	applications := append([]*Application{}, c.Applications...)
+	for _, project := range c.Projects {
+		applications = append(applications, project.Applications...)
+	}
+
+	for _, application := range applications {
+		if !application.TLS {
+			continue
+		}
+
+		if application.Hostname == "" || application.Port == "" {
+			return fmt.Errorf("Local application '%s' needs a hostname and the port it listens on to be served over TLS", application.Name)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) checkFaultsSettings() error {
+	applications := append([]*Application{}, c.Applications...)
+	forwards := append([]*Forward{}, c.Forwards...)
+	for _, project := range c.Projects {
+		applications = append(applications, project.Applications...)
+		forwards = append(forwards, project.Forwards...)
+	}
+
+	for _, application := range applications {
+		if application.Faults == nil {
+			continue
+		}
+
+		if application.Hostname == "" || application.Port == "" {
+			return fmt.Errorf("Local application '%s' needs a hostname and the port it listens on to inject faults", application.Name)
+		}
+
+		if err := checkFaults(application.Faults); err != nil {
+			return fmt.Errorf("Invalid faults of local application '%s': %v", application.Name, err)
+		}
+	}
+
+	for _, forward := range forwards {
+		if forward.Values.Faults == nil {
+			continue
+		}
+
+		if err := checkFaults(forward.Values.Faults); err != nil {
+			return fmt.Errorf("Invalid faults of forward '%s': %v", forward.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func checkFaults(faults *Faults) error {
+	if faults.Latency < 0 || faults.Jitter < 0 {
+		return fmt.Errorf("latency and jitter cannot be negative")
+	}
+
+	if faults.ResetRate < 0 || faults.ResetRate > 1 || faults.ErrorRate < 0 || faults.ErrorRate > 1 {
+		return fmt.Errorf("reset and error rates must be between 0 and 1")
+	}
+
+	if faults.Bandwidth < 0 {
+		return fmt.Errorf("bandwidth cannot be negative")
+	}
+
+	if status := faults.GetErrorStatus(); status < 400 || status > 599 {
+		return fmt.Errorf("error status %d is not an HTTP error status", status)
+	}
+
+	return nil
+}
+
+func (c *Config) checkResolver() error {
+	resolver := c.Resolver.GetType()
+
+	if result, ok := AvailableResolvers[resolver]; !ok || !result {
+		return fmt.Errorf("The '%s' resolver is not managed, please use one of: hostfile, dns", resolver)
+	}
+
+	if resolver == ResolverDNS {
+		return nil
+	}
+
+	// Wildcard hostnames cannot be written into the hosts file
+	applications := append([]*Application{}, c.Applications...)
+	forwards := append([]*Forward{}, c.Forwards...)
+	for _, project := range c.Projects {
+		applications = append(applications, project.Applications...)
+		forwards = append(forwards, project.Forwards...)
+	}
+
+	for _, application := range applications {
+		if strings.Contains(application.Hostname, "*") {
+			return fmt.Errorf("Wildcard hostname '%s' of local application '%s' requires the 'dns' resolver", application.Hostname, application.Name)
+		}
+	}
+
+	for _, forward := range forwards {
+		if strings.Contains(forward.Values.Hostname, "*") {
+			return fmt.Errorf("Wildcard hostname '%s' of forward '%s' requires the 'dns' resolver", forward.Values.Hostname, forward.Name)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) checkGuardrails() error {
+	for index, guardrail := range c.Guardrails {
+		if result, ok := AvailableGuardrailActions[guardrail.GetAction()]; !ok || !result {
+			return fmt.Errorf("The '%s' action of guardrail #%d is not managed, please use one of: refuse, confirm", guardrail.Action, index+1)
+		}
+
+		for _, pattern := range []string{guardrail.Context, guardrail.Namespace} {
+			if _, err := compilePattern(pattern); err != nil {
+				return fmt.Errorf("Invalid pattern '%s' in guardrail #%d: %v", pattern, index+1, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func getConfigPath() string {
 	if value := os.Getenv("MONDAY_CONFIG_PATH"); value != "" {
 		return value
@@ -155,6 +370,27 @@ func getConfigPath() string {
 	return defaultConfigPath
 }
 
+// GetDataPath returns the directory where Monday stores its runtime data (control socket, state, ...)
+func GetDataPath() string {
+	if value := os.Getenv("MONDAY_DATA_PATH"); value != "" {
+		return value
+	}
+
+	return defaultDataPath
+}
+
+// EnsureDataPath creates the Monday runtime data directory if it does not exist yet
+// and returns its path
+func EnsureDataPath() (string, error) {
+	path := GetDataPath()
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return "", fmt.Errorf("Unable to create Monday data directory '%s': %v", path, err)
+	}
+
+	return path, nil
+}
+
 func setConfigFilePaths() {
 	Filepath = fmt.Sprintf("%s/%s", getConfigPath(), Filename)
 	MultipleFilepath = fmt.Sprintf("%s/%s", getConfigPath(), MultipleFilenamePattern)
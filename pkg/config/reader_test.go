@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -162,3 +163,214 @@ func TestGetProjectByNameWhenProjectNotFound(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, "Unable to find project name 'unknown-project' in the configuration", err.Error())
 }
+
+func TestCheckRunSettingsWhenUnknownRestartPolicy(t *testing.T) {
+	// Given
+	conf := &Config{
+		Projects: []*Project{
+			{
+				Name: "backend",
+				Applications: []*Application{
+					{Name: "api", Run: &Run{Restart: "sometimes"}},
+				},
+			},
+		},
+	}
+
+	// When
+	err := conf.checkRunSettings()
+
+	// Then
+	assert.EqualError(t, err, "The 'sometimes' restart policy of local application 'api' is not managed, please use one of: never, on-failure, always")
+}
+
+func TestCheckRunSettingsWhenUnknownStopSignal(t *testing.T) {
+	// Given
+	conf := &Config{
+		Applications: []*Application{
+			{Name: "api", Run: &Run{StopSignal: "SIGSTOP"}},
+		},
+	}
+
+	// When
+	err := conf.checkRunSettings()
+
+	// Then
+	assert.EqualError(t, err, "Invalid run section of local application 'api': unknown stop signal 'SIGSTOP'")
+}
+
+func TestCheckGuardrailsWhenUnknownAction(t *testing.T) {
+	// Given
+	conf := &Config{
+		Guardrails: []*Guardrail{
+			{Context: "*prod*"},
+			{Namespace: "payment", Action: "warn"},
+		},
+	}
+
+	// When
+	err := conf.checkGuardrails()
+
+	// Then
+	assert.EqualError(t, err, "The 'warn' action of guardrail #2 is not managed, please use one of: refuse, confirm")
+}
+
+func TestCheckGuardrailsWhenInvalidPattern(t *testing.T) {
+	// Given
+	conf := &Config{
+		Guardrails: []*Guardrail{
+			{Context: "[prod"},
+		},
+	}
+
+	// When
+	err := conf.checkGuardrails()
+
+	// Then
+	assert.EqualError(t, err, "Invalid pattern '[prod' in guardrail #1: syntax error in pattern")
+}
+
+func TestGetDataPath(t *testing.T) {
+	// Given
+	os.Setenv("MONDAY_DATA_PATH", "/tmp/custom/.monday")
+	defer os.Setenv("MONDAY_DATA_PATH", "")
+
+	// When
+	path := GetDataPath()
+
+	// Then
+	assert.Equal(t, "/tmp/custom/.monday", path)
+}
+
+func TestCheckEnvFromSettingsWhenUnknownKind(t *testing.T) {
+	// Given
+	conf := &Config{
+		Applications: []*Application{
+			{Name: "api", Run: &Run{EnvFrom: []*EnvFrom{{Kind: "vault", Name: "api"}}}},
+		},
+	}
+
+	// When
+	err := conf.checkEnvFromSettings()
+
+	// Then
+	assert.EqualError(t, err, "The 'vault' env_from kind of local application 'api' is not managed, please use one of: configmap, secret")
+}
+
+func TestCheckEnvFromSettingsWhenNoName(t *testing.T) {
+	// Given
+	conf := &Config{
+		Projects: []*Project{
+			{
+				Name: "project",
+				Applications: []*Application{
+					{Name: "api", Build: &Build{EnvFrom: []*EnvFrom{{Kind: EnvFromSecret}}}},
+				},
+			},
+		},
+	}
+
+	// When
+	err := conf.checkEnvFromSettings()
+
+	// Then
+	assert.EqualError(t, err, "Please provide the name of the secret to read environment variables from in local application 'api'")
+}
+
+func TestCheckTLSSettingsWhenNoPort(t *testing.T) {
+	// Given
+	conf := &Config{
+		Applications: []*Application{
+			{Name: "front", Hostname: "front.svc.local"},
+			{Name: "graphql", Hostname: "graphql.svc.local", TLS: true},
+		},
+	}
+
+	// When
+	err := conf.checkTLSSettings()
+
+	// Then
+	assert.EqualError(t, err, "Local application 'graphql' needs a hostname and the port it listens on to be served over TLS")
+}
+
+func TestCheckFaultsSettingsWhenNoPort(t *testing.T) {
+	// Given
+	conf := &Config{
+		Applications: []*Application{
+			{Name: "graphql", Hostname: "graphql.svc.local", Faults: &Faults{Latency: 200 * time.Millisecond}},
+		},
+	}
+
+	// When
+	err := conf.checkFaultsSettings()
+
+	// Then
+	assert.EqualError(t, err, "Local application 'graphql' needs a hostname and the port it listens on to inject faults")
+}
+
+func TestCheckFaultsSettingsWhenInvalidRate(t *testing.T) {
+	// Given
+	conf := &Config{
+		Projects: []*Project{
+			{
+				Name: "my-project",
+				Forwards: []*Forward{
+					{Name: "user-api", Values: ForwardValues{Faults: &Faults{ErrorRate: 5}}},
+				},
+			},
+		},
+	}
+
+	// When
+	err := conf.checkFaultsSettings()
+
+	// Then
+	assert.EqualError(t, err, "Invalid faults of forward 'user-api': reset and error rates must be between 0 and 1")
+}
+
+func TestCheckFaultsSettingsWhenInvalidErrorStatus(t *testing.T) {
+	// Given
+	conf := &Config{
+		Forwards: []*Forward{
+			{Name: "user-api", Values: ForwardValues{Faults: &Faults{ErrorRate: 0.1, ErrorStatus: 200}}},
+		},
+	}
+
+	// When
+	err := conf.checkFaultsSettings()
+
+	// Then
+	assert.EqualError(t, err, "Invalid faults of forward 'user-api': error status 200 is not an HTTP error status")
+}
+
+func TestCheckResolverWhenUnknownType(t *testing.T) {
+	// Given
+	conf := &Config{Resolver: &Resolver{Type: "mdns"}}
+
+	// When
+	err := conf.checkResolver()
+
+	// Then
+	assert.EqualError(t, err, "The 'mdns' resolver is not managed, please use one of: hostfile, dns")
+}
+
+func TestCheckResolverWhenWildcardHostnameInHostfile(t *testing.T) {
+	// Given
+	conf := &Config{
+		Forwards: []*Forward{
+			{Name: "graphql", Values: ForwardValues{Hostname: "*.graphql.svc.local"}},
+		},
+	}
+
+	// When
+	err := conf.checkResolver()
+
+	// Then
+	assert.EqualError(t, err, "Wildcard hostname '*.graphql.svc.local' of forward 'graphql' requires the 'dns' resolver")
+
+	// When
+	conf.Resolver = &Resolver{Type: ResolverDNS}
+
+	// Then
+	assert.Nil(t, conf.checkResolver())
+}
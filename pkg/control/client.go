@@ -0,0 +1,152 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/eko/monday/pkg/inspect"
+)
+
+var (
+	// ErrNotRunning is returned when no Monday instance is listening on the control socket
+	ErrNotRunning = errors.New("no running Monday instance found, please start one using 'monday run --detach'")
+)
+
+// Client calls the control API of a running Monday instance over its unix socket
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient initializes a new control API client using the given unix socket path
+func NewClient(socketPath string) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Status returns the status of the running Monday instance
+func (c *Client) Status() (*StatusResponse, error) {
+	response, err := c.do(http.MethodGet, "/status")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var status StatusResponse
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("unable to decode status response: %v", err)
+	}
+
+	return &status, nil
+}
+
+// Stop asks the running Monday instance to stop all its applications and forwards
+func (c *Client) Stop() error {
+	response, err := c.do(http.MethodPost, "/stop")
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// Restart asks the running Monday instance to restart the given local application
+func (c *Client) Restart(name string) error {
+	response, err := c.do(http.MethodPost, fmt.Sprintf("/applications/%s/restart", url.PathEscape(name)))
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// Logs writes the logs of the given application (or pod) name into the given writer.
+// When follow is true, it keeps writing new lines until the connection is closed.
+func (c *Client) Logs(name string, follow bool, writer io.Writer) error {
+	response, err := c.do(http.MethodGet, fmt.Sprintf("/logs/%s?follow=%t", url.PathEscape(name), follow))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	_, err = io.Copy(writer, response.Body)
+
+	return err
+}
+
+// Requests returns the HTTP exchanges captured by the inspector of the running Monday instance
+func (c *Client) Requests() ([]*inspect.Exchange, error) {
+	response, err := c.do(http.MethodGet, "/inspector/requests")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var exchanges []*inspect.Exchange
+	if err := json.NewDecoder(response.Body).Decode(&exchanges); err != nil {
+		return nil, fmt.Errorf("unable to decode inspector requests response: %v", err)
+	}
+
+	return exchanges, nil
+}
+
+// Replay asks the running Monday instance to send again the captured request of the given identifier
+// and returns the new exchange
+func (c *Client) Replay(id int) (*inspect.Exchange, error) {
+	response, err := c.do(http.MethodPost, fmt.Sprintf("/inspector/requests/%d/replay", id))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var exchange inspect.Exchange
+	if err := json.NewDecoder(response.Body).Decode(&exchange); err != nil {
+		return nil, fmt.Errorf("unable to decode replay response: %v", err)
+	}
+
+	return &exchange, nil
+}
+
+func (c *Client) do(method, path string) (*http.Response, error) {
+	// Host is not used when dialing the unix socket
+	request, err := http.NewRequest(method, "http://monday"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		var netErr *net.OpError
+		if errors.As(err, &netErr) && netErr.Op == "dial" {
+			return nil, ErrNotRunning
+		}
+
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		defer response.Body.Close()
+
+		var errorResponse ErrorResponse
+		if err := json.NewDecoder(response.Body).Decode(&errorResponse); err != nil || errorResponse.Error == "" {
+			return nil, fmt.Errorf("unexpected response from Monday instance: %s", response.Status)
+		}
+
+		return nil, errors.New(errorResponse.Error)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,22 @@
+package control
+
+// StatusResponse represents the status of a running Monday instance
+type StatusResponse struct {
+	PID          int                 `json:"pid"`
+	Project      string              `json:"project"`
+	Applications []ApplicationStatus `json:"applications"`
+	Forwards     []string            `json:"forwards"`
+}
+
+// ApplicationStatus represents the status of a local application
+type ApplicationStatus struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	ExitCode int    `json:"exit_code"`
+	Restarts int    `json:"restarts"`
+}
+
+// ErrorResponse represents an error returned by the control API
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,253 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/inspect"
+	"github.com/eko/monday/pkg/log"
+	"github.com/eko/monday/pkg/run"
+)
+
+var errInspectorNotEnabled = errors.New("HTTP inspector is not enabled in the running project, please set 'inspect: true' on a forward")
+
+const (
+	// SocketFilename is the name of the unix socket exposing the control API in Monday data directory
+	SocketFilename = "monday.sock"
+)
+
+// Server exposes an API allowing to control a running Monday instance
+type Server interface {
+	Listen() error
+	Close() error
+}
+
+type server struct {
+	socketPath string
+	runner     run.Runner
+	project    *config.Project
+	stop       func()
+	inspector  *inspect.Inspector
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+// NewServer initializes a new control API server that will listen on the given unix socket path
+func NewServer(socketPath string, runner run.Runner, project *config.Project, stop func()) *server {
+	s := &server{
+		socketPath: socketPath,
+		runner:     runner,
+		project:    project,
+		stop:       stop,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /stop", s.handleStop)
+	mux.HandleFunc("POST /applications/{name}/restart", s.handleRestart)
+	mux.HandleFunc("GET /logs/{name}", s.handleLogs)
+	mux.HandleFunc("GET /inspector/requests", s.handleInspectorRequests)
+	mux.HandleFunc("POST /inspector/requests/{id}/replay", s.handleInspectorReplay)
+
+	s.httpServer = &http.Server{Handler: mux}
+
+	return s
+}
+
+// SetInspector sets the inspector whose captured HTTP exchanges are exposed by the control API
+func (s *server) SetInspector(inspector *inspect.Inspector) {
+	s.inspector = inspector
+}
+
+// GetSocketPath returns the path of the unix socket exposing the control API
+func GetSocketPath() string {
+	return fmt.Sprintf("%s/%s", config.GetDataPath(), SocketFilename)
+}
+
+// Listen opens the unix socket and serves the control API in a separated goroutine
+func (s *server) Listen() error {
+	// Another instance answering on this socket means we should not steal it
+	if conn, err := net.Dial("unix", s.socketPath); err == nil {
+		conn.Close()
+		return fmt.Errorf("another Monday instance is already listening on '%s'", s.socketPath)
+	}
+
+	// Remove a stale socket left by a previous instance
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("unable to listen on control socket '%s': %v", s.socketPath, err)
+	}
+
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.listener = listener
+
+	go s.httpServer.Serve(listener)
+
+	return nil
+}
+
+// Close stops serving the control API and removes the unix socket
+func (s *server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+
+	err := s.httpServer.Close()
+	os.Remove(s.socketPath)
+
+	return err
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	response := StatusResponse{
+		PID:          os.Getpid(),
+		Project:      s.project.Name,
+		Applications: make([]ApplicationStatus, 0, len(s.project.Applications)),
+		Forwards:     make([]string, 0, len(s.project.Forwards)),
+	}
+
+	for _, application := range s.project.Applications {
+		status, ok := s.runner.GetStatus(application.Name)
+		if !ok {
+			status = run.Status{State: run.StatePending}
+		}
+
+		response.Applications = append(response.Applications, ApplicationStatus{
+			Name:     application.Name,
+			State:    status.State,
+			ExitCode: status.ExitCode,
+			Restarts: status.Restarts,
+		})
+	}
+
+	for _, forward := range s.project.Forwards {
+		response.Forwards = append(response.Forwards, forward.Name)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *server) handleStop(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	go s.stop()
+}
+
+func (s *server) handleRestart(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	application, err := s.getApplication(name)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	s.runner.Restart(application)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+
+	lines, channel, unsubscribe := log.Logs.Subscribe(name)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	writer := bufio.NewWriter(w)
+	for _, line := range lines {
+		writer.WriteString(line)
+	}
+	writer.Flush()
+
+	if !follow {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case line := <-channel:
+			if _, err := w.Write([]byte(line)); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *server) handleInspectorRequests(w http.ResponseWriter, r *http.Request) {
+	if s.inspector == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: errInspectorNotEnabled.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.inspector.GetExchanges())
+}
+
+func (s *server) handleInspectorReplay(w http.ResponseWriter, r *http.Request) {
+	if s.inspector == nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: errInspectorNotEnabled.Error()})
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid request identifier '%s'", r.PathValue("id"))})
+		return
+	}
+
+	exchange, err := s.inspector.Replay(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, exchange)
+}
+
+func (s *server) getApplication(name string) (*config.Application, error) {
+	for _, application := range s.project.Applications {
+		if application.Name == name {
+			return application, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to find local application '%s' in the running project", name)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(value)
+}
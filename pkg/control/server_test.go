@@ -0,0 +1,255 @@
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/inspect"
+	"github.com/eko/monday/pkg/log"
+	"github.com/eko/monday/pkg/run"
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStatus(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	runner := run.NewMockRunner(ctrl)
+	runner.EXPECT().GetStatus("test-app").Return(run.Status{State: run.StateReady, Restarts: 1}, true)
+	runner.EXPECT().GetStatus("test-other-app").Return(run.Status{}, false)
+
+	client := startServer(t, runner, nil)
+
+	// When
+	status, err := client.Status()
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, &StatusResponse{
+		PID:     os.Getpid(),
+		Project: "My project name",
+		Applications: []ApplicationStatus{
+			{Name: "test-app", State: run.StateReady, Restarts: 1},
+			{Name: "test-other-app", State: run.StatePending},
+		},
+		Forwards: []string{"test-forward"},
+	}, status)
+}
+
+func TestRestart(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	runner := run.NewMockRunner(ctrl)
+	client := startServer(t, runner, nil)
+
+	runner.EXPECT().Restart(getProjectMock().Applications[0])
+
+	// When
+	err := client.Restart("test-app")
+
+	// Then
+	assert.Nil(t, err)
+}
+
+func TestRestartWhenUnknownApplication(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	runner := run.NewMockRunner(ctrl)
+	client := startServer(t, runner, nil)
+
+	// When
+	err := client.Restart("unknown-app")
+
+	// Then
+	assert.EqualError(t, err, "unable to find local application 'unknown-app' in the running project")
+}
+
+func TestStop(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stopped := make(chan struct{})
+
+	runner := run.NewMockRunner(ctrl)
+	client := startServer(t, runner, func() {
+		close(stopped)
+	})
+
+	// When
+	err := client.Stop()
+
+	// Then
+	assert.Nil(t, err)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop function has not been called")
+	}
+}
+
+func TestLogs(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log.Logs.Publish("test-logs-app", "first line\n")
+	log.Logs.Publish("test-logs-app", "second line\n")
+
+	runner := run.NewMockRunner(ctrl)
+	client := startServer(t, runner, nil)
+
+	buffer := bytes.NewBuffer(nil)
+
+	// When
+	err := client.Logs("test-logs-app", false, buffer)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "first line\nsecond line\n", buffer.String())
+}
+
+func TestClientWhenNotRunning(t *testing.T) {
+	// Given
+	client := NewClient("/tmp/monday-unknown.sock")
+
+	// When
+	_, err := client.Status()
+
+	// Then
+	assert.Equal(t, ErrNotRunning, err)
+}
+
+func TestInspectorRequests(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef(gomock.Any(), gomock.Any()).AnyTimes()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer target.Close()
+
+	inspector := inspect.NewInspector(view)
+	captureRequest(t, inspector, target.Listener.Addr().String(), "POST /users HTTP/1.1\r\nHost: api.svc.local\r\nContent-Length: 2\r\n\r\n{}")
+
+	client := startServer(t, run.NewMockRunner(ctrl), nil, func(s *server) {
+		s.SetInspector(inspector)
+	})
+
+	// When
+	exchanges, err := client.Requests()
+	replayed, replayErr := client.Replay(1)
+	_, unknownErr := client.Replay(42)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Len(t, exchanges, 1)
+	assert.Equal(t, "POST", exchanges[0].Method)
+	assert.Equal(t, "/users", exchanges[0].Path)
+	assert.Equal(t, []byte("{}"), exchanges[0].RequestBody)
+	assert.Equal(t, http.StatusCreated, exchanges[0].Status)
+
+	assert.Nil(t, replayErr)
+	assert.Equal(t, 2, replayed.ID)
+	assert.Equal(t, 1, replayed.ReplayOf)
+	assert.Equal(t, http.StatusCreated, replayed.Status)
+
+	assert.EqualError(t, unknownErr, "Request #42 has not been captured (or is not kept anymore)")
+}
+
+func TestInspectorRequestsWhenNotEnabled(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := startServer(t, run.NewMockRunner(ctrl), nil)
+
+	// When
+	_, err := client.Requests()
+
+	// Then
+	assert.EqualError(t, err, "HTTP inspector is not enabled in the running project, please set 'inspect: true' on a forward")
+}
+
+func startServer(t *testing.T, runner run.Runner, stop func(), options ...func(*server)) *Client {
+	directory, err := os.MkdirTemp("", "monday")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socketPath := directory + "/" + SocketFilename
+
+	server := NewServer(socketPath, runner, getProjectMock(), stop)
+	for _, option := range options {
+		option(server)
+	}
+
+	if err := server.Listen(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		server.Close()
+		os.RemoveAll(directory)
+	})
+
+	return NewClient(socketPath)
+}
+
+// captureRequest sends the given raw request to the given target through the inspector
+func captureRequest(t *testing.T, inspector *inspect.Inspector, target, request string) {
+	client, proxyClient := net.Pipe()
+	defer client.Close()
+
+	server, err := net.Dial("tcp", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	go inspect.ProxyHTTP(proxyClient, server, inspector.Hook("api", target, "http"))
+
+	go client.Write([]byte(request))
+
+	response, err := http.ReadResponse(bufio.NewReader(client), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	assert.Eventually(t, func() bool { return len(inspector.GetExchanges()) == 1 }, 5*time.Second, 10*time.Millisecond)
+}
+
+var projectMock = &config.Project{
+	Name: "My project name",
+	Applications: []*config.Application{
+		{Name: "test-app"},
+		{Name: "test-other-app"},
+	},
+	Forwards: []*config.Forward{
+		{Name: "test-forward"},
+	},
+}
+
+func getProjectMock() *config.Project {
+	return projectMock
+}
@@ -16,6 +16,11 @@ import (
 	"github.com/eko/monday/pkg/ui"
 )
 
+var (
+	// discoverPorts returns the ports of Kubernetes forwards which do not specify any
+	discoverPorts = kubernetes.DiscoverPorts
+)
+
 // Forwarder represents all kinds of forwarders (Kubernetes, others...)
 type Forwarder interface {
 	ForwardAll(ctx context.Context)
@@ -33,15 +38,18 @@ type ForwarderType interface {
 // forwarder is the struct that manage running local applications
 type forwarder struct {
 	view       ui.View
+	logsView   ui.View
 	proxy      proxy.Proxy
 	forwards   []*config.Forward
 	forwarders sync.Map
 }
 
-// NewForwarder instanciates a Forwarder struct from configuration data
-func NewForwarder(view ui.View, proxy proxy.Proxy, project *config.Project) *forwarder {
+// NewForwarder instanciates a Forwarder struct from configuration data. Logs of forwarded
+// applications, when followed, are written to the given logs view
+func NewForwarder(view ui.View, logsView ui.View, proxy proxy.Proxy, project *config.Project) *forwarder {
 	return &forwarder{
 		view:     view,
+		logsView: logsView,
 		proxy:    proxy,
 		forwards: project.Forwards,
 	}
@@ -102,6 +110,19 @@ func (f *forwarder) forward(ctx context.Context, forward *config.Forward, wg *sy
 
 	values := forward.Values
 
+	// Ports of Kubernetes forwards are the ones of the targeted pod when they are not specified
+	if forward.Type == config.ForwarderKubernetes && len(values.Ports) == 0 {
+		ports, err := discoverPorts(ctx, f.view, forward.Name, values)
+		if err != nil {
+			f.view.Writef("❌  %s\n", err.Error())
+			return
+		}
+
+		f.view.Writef("🔍  Discovered ports of '%s': %s\n", forward.Name, strings.Join(ports, ", "))
+
+		values.Ports = ports
+	}
+
 	// Initiates proxy for port-forwarding with hostnames
 	proxifiedPorts := make([]string, 0)
 	proxyForwards := make([]*proxy.ProxyForward, 0)
@@ -130,6 +151,11 @@ func (f *forwarder) forward(ctx context.Context, forward *config.Forward, wg *sy
 				proxyForward = proxy.NewProxyForward(forward.Name, values.Hostname, values.ProxyHostname, localPort, forwardPort)
 			}
 
+			proxyForward.SetVirtualHost(forward.GetProxyMode() == config.ProxyModeHTTP)
+			proxyForward.SetTLS(values.TLS)
+			proxyForward.SetInspect(values.Inspect)
+			proxyForward.SetFaults(proxy.NewFaults(values.Faults))
+
 			proxyForwards = append(proxyForwards, proxyForward)
 			f.proxy.AddProxyForward(forward.Name, proxyForward)
 			proxifiedPorts = append(proxifiedPorts, proxyForward.GetProxifiedPorts())
@@ -144,66 +170,129 @@ func (f *forwarder) forward(ctx context.Context, forward *config.Forward, wg *sy
 		if forward.IsProxified() {
 			forwardPorts = proxifiedPorts
 		}
-		forwarder, err := kubernetes.NewForwarder(f.view, forward.Type, forward.Name, values.Context, values.Namespace, forwardPorts, values.Labels)
+		forwarder, err := kubernetes.NewForwarder(f.view, forward.Type, forward.Name, values.Context, values.Namespace, forwardPorts, values.Labels, values.Target)
 		if err != nil {
 			f.view.Writef("❌  %s\n", err.Error())
 			return
 		}
 
+		if err := forwarder.CheckPermissions(ctx); err != nil {
+			f.view.Writef("❌  %s\n", err.Error())
+			return
+		}
+
+		forwarder.SetTransport(values.Transport)
+		forwarder.SetLogs(kubernetes.LogsOptions{
+			Enabled:   values.Logs,
+			Container: values.LogsContainer,
+			Tail:      values.LogsTail,
+			View:      f.logsView,
+		})
+
 		f.addForwarder(forward.Name, forwarder)
 
 	// Kubernetes remote forward: open both a SSH remote-forward connection and a Kubernetes port-forward, use proxy
 	case config.ForwarderKubernetesRemote:
+		// Proxy only allows connections authenticated with a key generated for this session
+		proxyKey, err := ssh.NewProxyKey()
+		if err != nil {
+			f.view.Writef("❌  %s\n", err.Error())
+			return
+		}
+
 		// First, set pod's proxy
-		forwarder, err := kubernetes.NewForwarder(f.view, forward.Type, forward.Name, values.Context, values.Namespace, proxifiedPorts, values.Labels)
+		forwarder, err := kubernetes.NewForwarder(f.view, forward.Type, forward.Name, values.Context, values.Namespace, proxifiedPorts, values.Labels, values.Target)
 		if err != nil {
 			f.view.Writef("❌  %s\n", err.Error())
 			return
 		}
 
-		f.addForwarder(forward.Name, forwarder)
+		if err := forwarder.CheckPermissions(ctx); err != nil {
+			f.view.Writef("❌  %s\n", err.Error())
+			return
+		}
+
+		forwarder.SetTransport(values.Transport)
 
-		// Then, ssh remote-forward for all specified ports to pod's container
+		interceptedPorts := make([]string, 0, len(values.Ports))
 		for _, ports := range values.Ports {
-			for _, proxyForward := range proxyForwards {
+			localPort, _ := splitLocalAndForwardPorts(ports)
+			interceptedPorts = append(interceptedPorts, localPort)
+		}
+
+		forwarder.SetRemoteOptions(kubernetes.RemoteOptions{
+			Interception:     values.Interception,
+			InterceptedPorts: interceptedPorts,
+			Container:        values.Container,
+			AllMatching:      values.AllMatching,
+			AuthorizedKey:    proxyKey.AuthorizedKey(),
+		})
+
+		f.addForwarder(forward.Name, forwarder)
+
+		// Then, ssh remote-forward for all specified ports to pod's container, over a single connection
+		for _, proxyForward := range proxyForwards {
+			mappings := make([]ssh.Mapping, 0, len(values.Ports))
+			for index, ports := range values.Ports {
 				localPort, forwardPort := splitLocalAndForwardPorts(ports)
-				values.Remote = "root@127.0.0.1"
-				values.Args = append(values.Args, fmt.Sprintf("-p %s", proxyForward.ProxyPort))
 
-				forwarder, err := ssh.NewForwarder(f.view, config.ForwarderSSHRemote, values, localPort, forwardPort)
-				if err != nil {
-					f.view.Writef("❌  %s\n", err.Error())
-					return
+				// In sidecar mode, pod traffic is redirected to dedicated ports of the proxy
+				if values.Interception == kubernetes.InterceptionSidecar {
+					localPort = strconv.Itoa(kubernetes.GetSidecarPort(index))
 				}
 
-				f.addForwarder(forward.Name, forwarder)
+				mappings = append(mappings, ssh.Mapping{LocalPort: localPort, ForwardPort: forwardPort})
 			}
-		}
 
-	// SSH local forward: give proxy port as local port and forwarded port, use proxy
-	case config.ForwarderSSH:
-		for _, proxyForward := range proxyForwards {
-			forwarder, err := ssh.NewForwarder(f.view, forward.Type, values, proxyForward.ProxyPort, proxyForward.ForwardPort)
+			// Proxy is reached through the Kubernetes port-forward, so SSH values of the forward do not apply
+			sshValues := config.ForwardValues{
+				ForwardHostname: values.ForwardHostname,
+				Remote:          "127.0.0.1",
+				User:            "root",
+				Port:            proxyForward.ProxyPort,
+			}
+
+			forwarder, err := ssh.NewForwarder(f.view, config.ForwarderSSHRemote, sshValues, mappings)
 			if err != nil {
 				f.view.Writef("❌  %s\n", err.Error())
 				return
 			}
 
+			forwarder.SetProxyKey(proxyKey)
+
 			f.addForwarder(forward.Name, forwarder)
 		}
 
+	// SSH local forward: give proxy port as local port and forwarded port, use proxy
+	case config.ForwarderSSH:
+		mappings := make([]ssh.Mapping, 0, len(proxyForwards))
+		for _, proxyForward := range proxyForwards {
+			mappings = append(mappings, ssh.Mapping{LocalPort: proxyForward.ProxyPort, ForwardPort: proxyForward.ForwardPort})
+		}
+
+		forwarder, err := ssh.NewForwarder(f.view, forward.Type, values, mappings)
+		if err != nil {
+			f.view.Writef("❌  %s\n", err.Error())
+			return
+		}
+
+		f.addForwarder(forward.Name, forwarder)
+
 	// SSH remote forward: give local port and forwarded port, do not proxy
 	case config.ForwarderSSHRemote:
+		mappings := make([]ssh.Mapping, 0, len(values.Ports))
 		for _, ports := range values.Ports {
 			localPort, forwardPort := splitLocalAndForwardPorts(ports)
-			forwarder, err := ssh.NewForwarder(f.view, forward.Type, values, localPort, forwardPort)
-			if err != nil {
-				f.view.Writef("❌  %s\n", err.Error())
-				return
-			}
+			mappings = append(mappings, ssh.Mapping{LocalPort: localPort, ForwardPort: forwardPort})
+		}
 
-			f.addForwarder(forward.Name, forwarder)
+		forwarder, err := ssh.NewForwarder(f.view, forward.Type, values, mappings)
+		if err != nil {
+			f.view.Writef("❌  %s\n", err.Error())
+			return
 		}
+
+		f.addForwarder(forward.Name, forwarder)
 	}
 
 	if forwarders, ok := f.forwarders.Load(forward.Name); ok {
@@ -239,8 +328,12 @@ func (f *forwarder) checkForwardEnvironment(forward *config.Forward) error {
 		return fmt.Errorf("The '%s' specified forward type named '%s' is not managed actually", forward.Type, forward.Name)
 	}
 
-	// Check if at least 1 port is filled
-	if len(forward.Values.Ports) < 1 {
+	if result, ok := config.AvailableProxyModes[forward.GetProxyMode()]; !ok || !result {
+		return fmt.Errorf("The '%s' proxy mode of forward '%s' is not managed, please use one of: tcp, http", forward.Values.ProxyMode, forward.Name)
+	}
+
+	// Check if at least 1 port is filled, Kubernetes forwards could discover them
+	if len(forward.Values.Ports) < 1 && forward.Type != config.ForwarderKubernetes {
 		return fmt.Errorf("The '%s' specified forward type named '%s' does not have any port to forward, please specify them", forward.Type, forward.Name)
 	}
 
@@ -2,7 +2,9 @@ package forward
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/eko/monday/pkg/config"
@@ -36,14 +38,16 @@ func TestNewForwarder(t *testing.T) {
 	}
 
 	view := ui.NewMockView(ctrl)
+	logsView := ui.NewMockView(ctrl)
 
 	// When
-	f := NewForwarder(view, proxyfier, project)
+	f := NewForwarder(view, logsView, proxyfier, project)
 
 	// Then
 	assert.IsType(t, new(forwarder), f)
 	assert.Implements(t, new(Forwarder), f)
 
+	assert.Equal(t, logsView, f.logsView)
 	assert.Equal(t, proxyfier, f.proxy)
 	assert.Equal(t, project.Forwards, f.forwards)
 }
@@ -76,8 +80,9 @@ func TestForwardAll(t *testing.T) {
 
 	view := ui.NewMockView(ctrl)
 	view.EXPECT().Writef("📡  Forwarding '%s' over %s...\n", "test-ssh-forward", "ssh")
+	view.EXPECT().Writef("%v\n👓  Forwarder: lost port-forward connection trying to reconnect...\n", gomock.Any()).AnyTimes()
 
-	forwarder := NewForwarder(view, proxyfier, project)
+	forwarder := NewForwarder(view, view, proxyfier, project)
 
 	// When
 	forwarder.ForwardAll(ctx)
@@ -119,8 +124,9 @@ func TestForwardRemoteSSH(t *testing.T) {
 
 	view := ui.NewMockView(ctrl)
 	view.EXPECT().Writef("📡  Forwarding '%s' over %s...\n", "test-ssh-forward", "ssh-remote")
+	view.EXPECT().Writef("%v\n👓  Forwarder: lost port-forward connection trying to reconnect...\n", gomock.Any()).AnyTimes()
 
-	forwarder := NewForwarder(view, proxy, project)
+	forwarder := NewForwarder(view, view, proxy, project)
 
 	// When
 	forwarder.ForwardAll(ctx)
@@ -136,3 +142,99 @@ func TestForwardRemoteSSH(t *testing.T) {
 		}
 	}
 }
+
+func TestForwardKubernetesDiscoversPorts(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defaultDiscoverPorts := discoverPorts
+	defer func() { discoverPorts = defaultDiscoverPorts }()
+
+	discoverPorts = func(ctx context.Context, view ui.View, name string, values config.ForwardValues) ([]string, error) {
+		return []string{"8000:8000", "9090:9090"}, nil
+	}
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+	proxyfier.EXPECT().AddProxyForward("test-kubernetes-forward", gomock.Any()).Times(2)
+
+	forward := &config.Forward{
+		Name: "test-kubernetes-forward",
+		Type: config.ForwarderKubernetes,
+		Values: config.ForwardValues{
+			Namespace: "backend",
+			Labels:    map[string]string{"app": "my-app"},
+		},
+	}
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("📡  Forwarding '%s' over %s...\n", "test-kubernetes-forward", "kubernetes")
+	view.EXPECT().Writef("🔍  Discovered ports of '%s': %s\n", "test-kubernetes-forward", "8000:8000, 9090:9090")
+	view.EXPECT().Writef("❌  %s\n", gomock.Any()).AnyTimes()
+
+	forwarder := NewForwarder(view, view, proxyfier, &config.Project{Forwards: []*config.Forward{forward}})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// When
+	forwarder.forward(ctx, forward, &wg)
+
+	// Then
+	assert.Empty(t, forward.Values.Ports)
+}
+
+func TestForwardKubernetesWhenPortsCannotBeDiscovered(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defaultDiscoverPorts := discoverPorts
+	defer func() { discoverPorts = defaultDiscoverPorts }()
+
+	discoverPorts = func(ctx context.Context, view ui.View, name string, values config.ForwardValues) ([]string, error) {
+		return nil, errors.New("No TCP port has been found to forward 'test-kubernetes-forward', please specify them")
+	}
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	forward := &config.Forward{
+		Name: "test-kubernetes-forward",
+		Type: config.ForwarderKubernetes,
+		Values: config.ForwardValues{
+			Namespace: "backend",
+			Labels:    map[string]string{"app": "my-app"},
+		},
+	}
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("📡  Forwarding '%s' over %s...\n", "test-kubernetes-forward", "kubernetes")
+	view.EXPECT().Writef("❌  %s\n", "No TCP port has been found to forward 'test-kubernetes-forward', please specify them")
+
+	forwarder := NewForwarder(view, view, proxyfier, &config.Project{Forwards: []*config.Forward{forward}})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	// When
+	forwarder.forward(ctx, forward, &wg)
+
+	// Then
+	_, ok := forwarder.forwarders.Load("test-kubernetes-forward")
+	assert.False(t, ok)
+}
+
+func TestCheckForwardEnvironmentWhenNoPorts(t *testing.T) {
+	// Given
+	forwarder := &forwarder{}
+
+	// When
+	errKubernetes := forwarder.checkForwardEnvironment(&config.Forward{Name: "graphql", Type: config.ForwarderKubernetes})
+	errSSH := forwarder.checkForwardEnvironment(&config.Forward{Name: "website", Type: config.ForwarderSSH})
+
+	// Then
+	assert.Nil(t, errKubernetes)
+	assert.EqualError(t, errSSH, "The 'ssh' specified forward type named 'website' does not have any port to forward, please specify them")
+}
@@ -0,0 +1,47 @@
+package forward
+
+import (
+	"fmt"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/forward/kubernetes"
+)
+
+var (
+	resolveContext = kubernetes.ResolveContext
+)
+
+// ConfirmFunc asks the user to answer yes or no to the given question
+type ConfirmFunc func(question string) bool
+
+// CheckGuardrails ensures the given remote-forwards do not update workloads of a Kubernetes context and
+// namespace protected by the configuration guardrails, unless the user confirms it when allowed
+func CheckGuardrails(conf *config.Config, forwards []*config.Forward, confirm ConfirmFunc) error {
+	for _, forward := range forwards {
+		if forward.Type != config.ForwarderKubernetesRemote {
+			continue
+		}
+
+		context, namespace, err := resolveContext(forward.Values.Context, forward.Values.Namespace)
+		if err != nil {
+			return fmt.Errorf("Unable to resolve Kubernetes context of remote-forward '%s' to check guardrails: %v", forward.Name, err)
+		}
+
+		guardrail := conf.GetGuardrail(context, namespace)
+		if guardrail == nil {
+			continue
+		}
+
+		if guardrail.GetAction() == config.GuardrailRefuse {
+			return fmt.Errorf("Remote-forward '%s' is refused by guardrails on context '%s' and namespace '%s'", forward.Name, context, namespace)
+		}
+
+		question := fmt.Sprintf("Remote-forward '%s' will deploy the proxy on context '%s' and namespace '%s', do you want to continue", forward.Name, context, namespace)
+
+		if !confirm(question) {
+			return fmt.Errorf("Remote-forward '%s' on context '%s' and namespace '%s' has not been confirmed", forward.Name, context, namespace)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package forward
+
+import (
+	"testing"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckGuardrails(t *testing.T) {
+	// Given
+	mockResolveContext(t)
+
+	conf := &config.Config{
+		Guardrails: []*config.Guardrail{
+			{Context: "*prod*", Action: config.GuardrailRefuse},
+		},
+	}
+
+	forwards := []*config.Forward{
+		{Name: "prod-port-forward", Type: config.ForwarderKubernetes, Values: config.ForwardValues{Context: "eu-prod-1"}},
+		{Name: "staging-remote-forward", Type: config.ForwarderKubernetesRemote, Values: config.ForwardValues{Context: "staging"}},
+	}
+
+	// When
+	err := CheckGuardrails(conf, forwards, func(string) bool {
+		t.Fatal("Confirmation should not be asked")
+		return false
+	})
+
+	// Then
+	assert.Nil(t, err)
+}
+
+func TestCheckGuardrailsWhenRefused(t *testing.T) {
+	// Given
+	mockResolveContext(t)
+
+	conf := &config.Config{
+		Guardrails: []*config.Guardrail{
+			{Context: "*prod*"},
+		},
+	}
+
+	forwards := []*config.Forward{
+		{Name: "prod-remote-forward", Type: config.ForwarderKubernetesRemote, Values: config.ForwardValues{Context: "eu-prod-1"}},
+	}
+
+	// When
+	err := CheckGuardrails(conf, forwards, func(string) bool { return true })
+
+	// Then
+	assert.EqualError(t, err, "Remote-forward 'prod-remote-forward' is refused by guardrails on context 'eu-prod-1' and namespace 'default'")
+}
+
+func TestCheckGuardrailsWhenConfirmationIsNeeded(t *testing.T) {
+	// Given
+	mockResolveContext(t)
+
+	conf := &config.Config{
+		Guardrails: []*config.Guardrail{
+			{Context: "staging", Namespace: "payment", Action: config.GuardrailConfirm},
+		},
+	}
+
+	forwards := []*config.Forward{
+		{Name: "payment-remote-forward", Type: config.ForwarderKubernetesRemote, Values: config.ForwardValues{Context: "staging", Namespace: "payment"}},
+	}
+
+	var question string
+
+	// When
+	errConfirmed := CheckGuardrails(conf, forwards, func(q string) bool {
+		question = q
+		return true
+	})
+	errNotConfirmed := CheckGuardrails(conf, forwards, func(string) bool { return false })
+
+	// Then
+	assert.Nil(t, errConfirmed)
+	assert.Equal(t, "Remote-forward 'payment-remote-forward' will deploy the proxy on context 'staging' and namespace 'payment', do you want to continue", question)
+
+	assert.EqualError(t, errNotConfirmed, "Remote-forward 'payment-remote-forward' on context 'staging' and namespace 'payment' has not been confirmed")
+}
+
+func mockResolveContext(t *testing.T) {
+	defaultResolveContext := resolveContext
+	t.Cleanup(func() { resolveContext = defaultResolveContext })
+
+	resolveContext = func(context, namespace string) (string, string, error) {
+		if namespace == "" {
+			namespace = "default"
+		}
+		return context, namespace, nil
+	}
+}
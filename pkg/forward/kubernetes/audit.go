@@ -0,0 +1,122 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// AuditLogFilename is the name of the file (in Monday data directory) recording every workload mutation
+	AuditLogFilename = "audit.log"
+
+	AuditActionIntercept = "intercept"
+	AuditActionRestore   = "restore"
+)
+
+var (
+	auditMux sync.Mutex
+)
+
+// AuditEntry is a mutation made on a Kubernetes workload, written as a JSON line in the audit log.
+// Images are the ones of the workload containers before and after the mutation.
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Action    string    `json:"action"`
+	Context   string    `json:"context"`
+	Namespace string    `json:"namespace"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Before    []string  `json:"before"`
+	After     []string  `json:"after"`
+}
+
+// GetAuditLogPath returns the path of the audit log file
+func GetAuditLogPath() string {
+	return filepath.Join(config.GetDataPath(), AuditLogFilename)
+}
+
+// writeAuditEntry appends the mutation made on the given workload into the audit log
+func writeAuditEntry(action, context, namespace string, w *workload, before []string) error {
+	context, namespace = resolveAuditContext(context, namespace)
+
+	entry := AuditEntry{
+		Time:      time.Now(),
+		User:      getAuditUser(),
+		Action:    action,
+		Context:   context,
+		Namespace: namespace,
+		Kind:      w.kind,
+		Name:      w.GetName(),
+		Before:    before,
+		After:     getContainerImages(w.template),
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("Unable to write audit log entry: %v", err)
+	}
+
+	auditMux.Lock()
+	defer auditMux.Unlock()
+
+	path := GetAuditLogPath()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("Unable to create audit log directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("Unable to open audit log file '%s': %v", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("Unable to write audit log file '%s': %v", path, err)
+	}
+
+	return nil
+}
+
+// resolveAuditContext records the context and namespace actually used when they are not specified
+func resolveAuditContext(context, namespace string) (string, string) {
+	resolvedContext, resolvedNamespace, err := ResolveContext(context, namespace)
+	if err != nil {
+		return context, namespace
+	}
+
+	return resolvedContext, resolvedNamespace
+}
+
+// getAuditUser returns the local user making the mutation, as "<username>@<hostname>"
+func getAuditUser() string {
+	username := "unknown"
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return username
+	}
+
+	return username + "@" + hostname
+}
+
+func getContainerImages(template *apiv1.PodTemplateSpec) []string {
+	images := make([]string, 0, len(template.Spec.Containers))
+	for _, container := range template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+
+	return images
+}
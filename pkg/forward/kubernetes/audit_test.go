@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAuditEntry(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	deployment := newInterceptionDeployment()
+	w := newDeploymentWorkload(deployment)
+
+	before := getContainerImages(w.template)
+	injectProxyReplace(w.template, 0, testAuthorizedKey)
+
+	// When
+	errIntercept := writeAuditEntry(AuditActionIntercept, "context-test", "backend", w, before)
+	errRestore := writeAuditEntry(AuditActionRestore, "context-test", "backend", w, []string{ProxyDockerImage})
+
+	// Then
+	assert.Nil(t, errIntercept)
+	assert.Nil(t, errRestore)
+
+	content, err := os.ReadFile(GetAuditLogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+
+	var entry AuditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, AuditActionIntercept, entry.Action)
+	assert.Equal(t, "context-test", entry.Context)
+	assert.Equal(t, "backend", entry.Namespace)
+	assert.Equal(t, TargetDeployment, entry.Kind)
+	assert.Equal(t, "my-remote-app", entry.Name)
+	assert.Equal(t, []string{"acme.tld/my-remote-app"}, entry.Before)
+	assert.Equal(t, []string{ProxyDockerImage}, entry.After)
+	assert.NotEmpty(t, entry.User)
+	assert.False(t, entry.Time.IsZero())
+}
+
+// useTempAuditLog writes the audit log of the test in a temporary data directory
+func useTempAuditLog(t *testing.T) {
+	t.Setenv("MONDAY_DATA_PATH", t.TempDir())
+}
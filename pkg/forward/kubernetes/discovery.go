@@ -0,0 +1,82 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/ui"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// DiscoverPorts returns the TCP ports of the pod (or the service) targeted by a forward which does not
+// specify any, mapped one-to-one as "<port>:<port>". The proxy port is skipped.
+func DiscoverPorts(ctx context.Context, view ui.View, name string, values config.ForwardValues) ([]string, error) {
+	forwarder, err := NewForwarder(view, config.ForwarderKubernetes, name, values.Context, values.Namespace, nil, values.Labels, values.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	if forwarder.target == "" && forwarder.getSelector() == "" {
+		return nil, ErrNoSelectorLabel
+	}
+
+	return forwarder.discoverPorts(ctx)
+}
+
+func (f *Forwarder) discoverPorts(ctx context.Context) ([]string, error) {
+	filter, service, err := f.getPodFilter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make([]int32, 0)
+
+	if service != nil {
+		// Service ports are translated to the container ones when forwarding
+		for _, port := range service.Spec.Ports {
+			if isPortDiscoverable(port.Name, port.Port, port.Protocol) {
+				numbers = append(numbers, port.Port)
+			}
+		}
+	} else {
+		pod, err := f.getPodForFilter(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, container := range pod.Spec.Containers {
+			for _, port := range container.Ports {
+				if isPortDiscoverable(port.Name, port.ContainerPort, port.Protocol) {
+					numbers = append(numbers, port.ContainerPort)
+				}
+			}
+		}
+	}
+
+	ports := make([]string, 0, len(numbers))
+	discovered := make(map[int32]bool)
+
+	for _, number := range numbers {
+		if discovered[number] {
+			continue
+		}
+
+		discovered[number] = true
+		ports = append(ports, fmt.Sprintf("%d:%d", number, number))
+	}
+
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("No TCP port has been found to forward '%s', please specify them", f.name)
+	}
+
+	return ports, nil
+}
+
+func isPortDiscoverable(name string, number int32, protocol apiv1.Protocol) bool {
+	if protocol != "" && protocol != apiv1.ProtocolTCP {
+		return false
+	}
+
+	return name != ProxyPortName && number != RemoteSSHProxyPort
+}
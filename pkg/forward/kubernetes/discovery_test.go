@@ -0,0 +1,87 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiscoverPortsFromPod(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	pod := newPod("my-app-5d8f7b9c4-bd4sk", corev1.PodRunning, true, time.Now())
+	pod.Spec.Containers = append(pod.Spec.Containers,
+		corev1.Container{
+			Name: "sidecar",
+			Ports: []corev1.ContainerPort{
+				{Name: "metrics", ContainerPort: 9090},
+				{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+				{Name: "web", ContainerPort: 8000},
+			},
+		},
+		corev1.Container{
+			Name: ProxySidecarName,
+			Ports: []corev1.ContainerPort{
+				{Name: ProxyPortName, ContainerPort: RemoteSSHProxyPort},
+			},
+		},
+	)
+
+	forwarder := newTargetForwarder("", pod)
+	forwarder.labels = map[string]string{"app": "my-app"}
+
+	// When
+	ports, err := forwarder.discoverPorts(ctx)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"8000:8000", "9090:9090"}, ports)
+}
+
+func TestDiscoverPortsFromService(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-service", Namespace: "backend"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "my-app"},
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80},
+				{Name: "grpc", Port: 9000},
+			},
+		},
+	}
+
+	forwarder := newTargetForwarder("service/my-service", service, newPod("my-app", corev1.PodRunning, true, time.Now()))
+
+	// When
+	ports, err := forwarder.discoverPorts(ctx)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"80:80", "9000:9000"}, ports)
+}
+
+func TestDiscoverPortsWhenNone(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	pod := newPod("my-app", corev1.PodRunning, true, time.Now())
+	pod.Spec.Containers[0].Ports = nil
+
+	forwarder := newTargetForwarder("pod/my-app", pod)
+	forwarder.name = "test-forward"
+
+	// When
+	ports, err := forwarder.discoverPorts(ctx)
+
+	// Then
+	assert.Nil(t, ports)
+	assert.EqualError(t, err, "No TCP port has been found to forward 'test-forward', please specify them")
+}
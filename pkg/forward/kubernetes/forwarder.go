@@ -4,24 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
 	"os"
-	"strings"
-	"time"
+	"sync"
 
 	"github.com/eko/monday/pkg/config"
 	"github.com/eko/monday/pkg/log"
+	"github.com/eko/monday/pkg/state"
 	"github.com/eko/monday/pkg/ui"
-	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/portforward"
-	"k8s.io/client-go/transport/spdy"
 )
 
 const (
@@ -29,9 +25,16 @@ const (
 	// to make a remote-forward on the Kubernetes pod to be able to next forward trafic locally
 	RemoteSSHProxyPort = 5022
 
-	// ProxyDockerImage is the path to the public Docker image acting as a proxy in the
+	// ProxyDockerImageName is the path to the public Docker image acting as a proxy in the
 	// Kubernetes cluster
-	ProxyDockerImage = "ekofr/monday-proxy"
+	ProxyDockerImageName = "ekofr/monday-proxy"
+
+	// ProxyDockerImageVersion is the version of the proxy image this client works with: the proxy
+	// only accepts the SSH key given in MONDAY_AUTHORIZED_KEY since 2.0.0
+	ProxyDockerImageVersion = "2.0.0"
+
+	// ProxyDockerImage is the versioned proxy image deployed into the Kubernetes cluster
+	ProxyDockerImage = ProxyDockerImageName + ":" + ProxyDockerImageVersion
 
 	// ProxyPortName is the name given to the SSH port used when deploying the proxy image into the
 	// cluster
@@ -42,13 +45,25 @@ var (
 	defaultKubeConfigPath = fmt.Sprintf("%s/%s", os.Getenv("HOME"), "/.kube/config")
 
 	// ErrNoSelectorLabel is returned when no selector label is provided in the configuration file.
-	ErrNoSelectorLabel = errors.New("please provide a selector of labels in order to use Kubernetes forwarding")
+	ErrNoSelectorLabel = errors.New("please provide a selector of labels or a target in order to use Kubernetes forwarding")
 )
 
-type DeploymentBackup struct {
-	OldImage   string
-	OldPorts   []apiv1.ContainerPort
-	Deployment *appsv1.Deployment
+// RemoteOptions are the options used to deploy the proxy in case of remote-forward
+type RemoteOptions struct {
+	// Interception is the way the proxy is deployed: InterceptionReplace (default) or InterceptionSidecar
+	Interception string
+
+	// InterceptedPorts are the ports whose traffic is redirected to the proxy in sidecar mode
+	InterceptedPorts []string
+
+	// Container is the name of the container replaced by the proxy, the first one if empty
+	Container string
+
+	// AllMatching deploys the proxy in all the workloads matching the selector of labels instead of the first one
+	AllMatching bool
+
+	// AuthorizedKey is the public key of the session, the only one the proxy allows to connect
+	AuthorizedKey string
 }
 
 type Forwarder struct {
@@ -62,13 +77,19 @@ type Forwarder struct {
 	namespace      string
 	ports          []string
 	labels         map[string]string
+	target         string
+	transport      string
+	remoteOptions  RemoteOptions
+	logsOptions    LogsOptions
 	portForwarders map[string]*portforward.PortForwarder
-	deployments    map[string]*DeploymentBackup
+	workloads      map[types.UID]*state.Deployment
+	stopped        bool
+	mux            sync.Mutex
 	stopChannel    chan struct{}
 	readyChannel   chan struct{}
 }
 
-func NewForwarder(view ui.View, forwardType, name, context, namespace string, ports []string, labels map[string]string) (*Forwarder, error) {
+func NewForwarder(view ui.View, forwardType, name, context, namespace string, ports []string, labels map[string]string, target string) (*Forwarder, error) {
 	kubeConfigPath := getKubeConfigPath()
 
 	clientConfig, err := initializeClientConfig(context, kubeConfigPath)
@@ -88,13 +109,14 @@ func NewForwarder(view ui.View, forwardType, name, context, namespace string, po
 		context:        context,
 		namespace:      namespace,
 		labels:         labels,
+		target:         target,
 		ports:          ports,
 		clientConfig:   clientConfig,
 		clientSet:      clientSet,
-		restClient:     clientSet.RESTClient(),
+		restClient:     clientSet.CoreV1().RESTClient(),
 		portForwarders: make(map[string]*portforward.PortForwarder, 0),
-		deployments:    make(map[string]*DeploymentBackup, 0),
-		stopChannel:    make(chan struct{}, 1),
+		workloads:      make(map[types.UID]*state.Deployment, 0),
+		stopChannel:    make(chan struct{}),
 		readyChannel:   make(chan struct{}),
 	}, nil
 }
@@ -114,6 +136,16 @@ func (f *Forwarder) GetStopChannel() chan struct{} {
 	return f.readyChannel
 }
 
+// SetTransport sets the transport of port-forward connections: TransportAuto (default), TransportWebSocket or TransportSPDY
+func (f *Forwarder) SetTransport(transport string) {
+	f.transport = transport
+}
+
+// SetRemoteOptions sets how the proxy is deployed in case of remote-forward
+func (f *Forwarder) SetRemoteOptions(options RemoteOptions) {
+	f.remoteOptions = options
+}
+
 // Forward method executes the local or remote port-forward depending on the given type
 func (f *Forwarder) Forward(ctx context.Context) error {
 	defer func() {
@@ -123,21 +155,32 @@ func (f *Forwarder) Forward(ctx context.Context) error {
 		}
 	}()
 
-	selector := f.getSelector()
-
-	if selector == "" {
+	if f.target == "" && f.getSelector() == "" {
 		return ErrNoSelectorLabel
 	}
 
+	if !isInterceptionSupported(f.remoteOptions.Interception) {
+		return fmt.Errorf("Unsupported interception mode '%s', please use '%s' or '%s'", f.remoteOptions.Interception, InterceptionReplace, InterceptionSidecar)
+	}
+
+	if !isTransportSupported(f.transport) {
+		return fmt.Errorf("Unsupported transport '%s', please use '%s', '%s' or '%s'", f.transport, TransportAuto, TransportWebSocket, TransportSPDY)
+	}
+
+	if f.isStopped() {
+		<-ctx.Done()
+		return nil
+	}
+
 	switch f.forwardType {
 	case config.ForwarderKubernetes:
-		err := f.forwardLocal(ctx, selector)
+		err := f.forwardLocal(ctx)
 		if err != nil {
 			return err
 		}
 
 	case config.ForwarderKubernetesRemote:
-		err := f.forwardRemote(ctx, selector)
+		err := f.forwardRemote(ctx)
 		if err != nil {
 			return err
 		}
@@ -148,38 +191,96 @@ func (f *Forwarder) Forward(ctx context.Context) error {
 
 // Stop stops the current forwarder
 func (f *Forwarder) Stop(ctx context.Context) error {
+	f.mux.Lock()
+	if !f.stopped {
+		f.stopped = true
+		close(f.stopChannel)
+	}
+
 	// Close port-forwards currently active connections
 	for _, portForwarder := range f.portForwarders {
 		portForwarder.Close()
 	}
 
-	deploymentsClient := f.clientSet.AppsV1().Deployments(f.namespace)
-
-	// Reset currently active remote-forward deployment proxies
-	for _, backup := range f.deployments {
-		selector := f.getSelector()
+	backups := make([]*state.Deployment, 0, len(f.workloads))
+	for _, backup := range f.workloads {
+		backups = append(backups, backup)
+	}
+	f.mux.Unlock()
 
-		deployments, err := deploymentsClient.List(ctx, metav1.ListOptions{LabelSelector: selector})
-		if err != nil {
+	// Reset currently active remote-forward workload proxies
+	for _, backup := range backups {
+		if err := restoreDeployment(ctx, f.clientSet, *backup); err != nil {
+			f.view.Writef("❌  An error has occured while stopping/resetting %s '%s': %v\n", backup.Kind, backup.Name, err)
 			continue
 		}
 
-		if len(deployments.Items) < 1 {
-			continue
-		}
+		state.Current.RemoveDeployment(f.context, f.namespace, backup.Kind, backup.Name)
+	}
+
+	return nil
+}
+
+// RestoreDeployment puts back the original specification of a workload that has been updated with
+// the proxy by a previous session (or its original container image and ports if the specification
+// has not been stored in annotation)
+func RestoreDeployment(ctx context.Context, backup state.Deployment) error {
+	clientConfig, err := initializeClientConfig(backup.Context, getKubeConfigPath())
+	if err != nil {
+		return err
+	}
 
-		// Take first pod matching at the moment, maybe we should take all?
-		deployment := deployments.Items[0]
+	clientSet, err := initializeClientSet(clientConfig)
+	if err != nil {
+		return err
+	}
 
-		deployment.Spec.Template.Spec.Containers[0].Image = backup.OldImage
-		deployment.Spec.Template.Spec.Containers[0].Ports = backup.OldPorts
+	return restoreDeployment(ctx, clientSet, backup)
+}
 
-		_, err = deploymentsClient.Update(ctx, &deployment, metav1.UpdateOptions{})
+func restoreDeployment(ctx context.Context, clientSet kubernetes.Interface, backup state.Deployment) error {
+	w, err := getWorkload(ctx, clientSet, backup.Namespace, backup.Kind, backup.Name)
+	if err != nil {
+		return err
+	}
+
+	// Workload has been deleted and created again since then: it does not run the proxy
+	if backup.UID != "" && string(w.GetUID()) != backup.UID {
+		return nil
+	}
+
+	before := getContainerImages(w.template)
+
+	restored, err := restorePodTemplate(w)
+	if err != nil {
+		return err
+	}
+
+	if !restored {
+		index, err := w.getContainer(backup.Container)
 		if err != nil {
-			f.view.Writef("❌  An error has occured while stopping/resetting a deployment: %v\n", err)
+			return err
+		}
+
+		container := w.template.Spec.Containers[index]
+
+		// Workload has already been restored (or updated since then): nothing to do
+		if !isProxyImage(container.Image) {
+			return nil
 		}
+
+		container.Image = backup.Image
+		container.Ports = backup.Ports
+		w.template.Spec.Containers[index] = container
+	}
+
+	if err := updateWorkload(ctx, clientSet, backup.Namespace, w); err != nil {
+		return err
 	}
 
+	// Audit log is best-effort: failing to write it must not prevent the workload from being restored
+	_ = writeAuditEntry(AuditActionRestore, backup.Context, backup.Namespace, w, before)
+
 	return nil
 }
 
@@ -187,124 +288,241 @@ func isPodRunning(pod *apiv1.Pod) bool {
 	return pod.Status.Phase == apiv1.PodRunning
 }
 
-func (f *Forwarder) forwardLocal(ctx context.Context, selector string) error {
-	pods, err := f.clientSet.CoreV1().Pods(f.namespace).List(
-		ctx,
-		metav1.ListOptions{LabelSelector: selector},
-	)
+// forwardLocal port-forwards to the best pod matching the target and switches to another pod as soon
+// as the serving one is not healthy anymore
+func (f *Forwarder) forwardLocal(ctx context.Context) error {
+	filter, service, err := f.getPodFilter(ctx)
 	if err != nil {
-		return fmt.Errorf("Unable to find pods for selector '%s': %w", selector, err)
+		return err
 	}
 
-	if len(pods.Items) < 1 {
-		return fmt.Errorf("No pod available for selector '%s': %w", selector, err)
-	}
+	return f.forwardPods(ctx, filter, service)
+}
+
+// forwardPods port-forwards to the best pod matching the given filter, switching to another one when needed
+func (f *Forwarder) forwardPods(ctx context.Context, filter *podFilter, service *apiv1.Service) error {
+	for {
+		runningPod, err := f.getPodForFilter(ctx, filter)
+		if err != nil {
+			return err
+		}
 
-	var	runningPod apiv1.Pod
-	foundRunningPod := false
+		ports := f.ports
 
-	for _, pod := range pods.Items {
-		if isPodRunning(&pod) {
-			runningPod = pod
-			foundRunningPod = true
-			break
+		// Remote-forward ports target the proxy container, which is not described by the workload
+		if f.forwardType == config.ForwarderKubernetes {
+			ports, err = translatePorts(f.ports, runningPod, service)
+			if err != nil {
+				return err
+			}
 		}
+
+		switched, err := f.forwardPod(ctx, filter, runningPod, ports)
+		if err != nil || !switched {
+			return err
+		}
+	}
+}
+
+// forwardPod port-forwards to the given pod. It blocks until the forwarder is stopped, the port-forward
+// fails or another pod has to serve the forward, in which case true is returned.
+func (f *Forwarder) forwardPod(ctx context.Context, filter *podFilter, runningPod *apiv1.Pod, ports []string) (bool, error) {
+	dialer, err := f.newDialer(runningPod.Name)
+	if err != nil {
+		return false, err
 	}
 
-	if !foundRunningPod {
-		return fmt.Errorf("No runnning pod available for selector '%s'", selector)
+	stdoutStream := log.NewStreamer(log.StdOut, runningPod.Name, f.view)
+	stderrStream := log.NewStreamer(log.StdErr, runningPod.Name, f.view)
+
+	// Each port-forward has its own channels so that it can be stopped when switching to another pod
+	stopChannel := make(chan struct{})
+	readyChannel := make(chan struct{})
+
+	fw, err := portforward.New(dialer, ports, stopChannel, readyChannel, stdoutStream, stderrStream)
+	if err != nil {
+		return false, err
 	}
-	
-	request := f.restClient.Post().Resource("pods").Namespace(f.namespace).Name(runningPod.Name).SubResource("portforward")
 
-	url := url.URL{
-		Scheme:   request.URL().Scheme,
-		Host:     request.URL().Host,
-		Path:     buildPath(request),
-		RawQuery: "timeout=30s",
+	f.setPortForwarder(fw)
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+
+	switchChannel := make(chan struct{})
+	go f.watchServingPod(watchCtx, filter, runningPod.Name, switchChannel)
+
+	// Logs are followed on the serving pod only, so the stream ends as soon as the forward switches pods
+	if f.logsOptions.Enabled {
+		go f.followPodLogs(watchCtx, runningPod)
 	}
 
-	transport, upgrader, err := spdy.RoundTripperFor(f.clientConfig)
+	go func() {
+		select {
+		case <-readyChannel:
+			f.view.Writef("🔌  Forward '%s' is served by pod '%s'\n", f.name, runningPod.Name)
+			f.notifyReady()
+		case <-watchCtx.Done():
+		}
+	}()
+
+	forwarded := make(chan error, 1)
+	go func() {
+		forwarded <- fw.ForwardPorts()
+	}()
+
+	select {
+	case err := <-forwarded:
+		return false, err
+
+	case <-switchChannel:
+		close(stopChannel)
+		<-forwarded
+		return true, nil
+
+	case <-f.stopChannel:
+		close(stopChannel)
+		<-forwarded
+		return false, nil
+	}
+}
+
+func (f *Forwarder) forwardRemote(ctx context.Context) error {
+	workloads, err := f.getWorkloads(ctx)
 	if err != nil {
 		return err
 	}
 
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", &url)
+	for _, w := range workloads {
+		if err := f.interceptWorkload(ctx, w); err != nil {
+			return err
+		}
+	}
 
-	stdoutStream := log.NewStreamer(log.StdOut, runningPod.Name, f.view)
-	stderrStream := log.NewStreamer(log.StdErr, runningPod.Name, f.view)
+	// Wait for the proxy to be rolled out so that traffic is not forwarded to the previous pods
+	for _, w := range workloads {
+		err := f.waitForRollout(ctx, w)
+		if errors.Is(err, errStopped) {
+			return nil
+		} else if err != nil {
+			return err
+		}
 
-	fw, err := portforward.New(dialer, f.ports, f.stopChannel, f.readyChannel, stdoutStream, stderrStream)
+		f.view.Writef("✅  Proxy is ready on application '%s'\n", w.GetName())
+	}
+
+	filter, _, err := f.getPodFilter(ctx)
 	if err != nil {
 		return err
 	}
 
-	f.portForwarders[f.name] = fw
+	// Workloads have been updated with proxy, now forward ports locally to a pod running it
+	filter.proxy = true
 
-	return fw.ForwardPorts()
+	return f.forwardPods(ctx, filter, nil)
 }
 
-func (f *Forwarder) forwardRemote(ctx context.Context, selector string) error {
-	deploymentsClient := f.clientSet.AppsV1().Deployments(f.namespace)
-
-	deployments, err := deploymentsClient.List(
-		ctx,
-		metav1.ListOptions{LabelSelector: selector},
-	)
+// interceptWorkload deploys the proxy in the given workload, recording its original state the first time
+func (f *Forwarder) interceptWorkload(ctx context.Context, w *workload) error {
+	index, err := w.getContainer(f.remoteOptions.Container)
 	if err != nil {
 		return err
 	}
 
-	if len(deployments.Items) < 1 {
-		return fmt.Errorf("No deployment available for selector '%s': %v", selector, err)
-	}
+	container := w.template.Spec.Containers[index]
+
+	f.mux.Lock()
+	_, intercepted := f.workloads[w.GetUID()]
+	f.mux.Unlock()
 
-	// Take first pod matching at the moment, maybe we should take all?
-	deployment := deployments.Items[0]
-	container := deployment.Spec.Template.Spec.Containers[0]
+	if !intercepted {
+		f.view.Writef("📡  Setting up proxy on application '%s', please wait for the rollout to be complete...\n", w.GetName())
+
+		backup := &state.Deployment{
+			Context:   f.context,
+			Namespace: f.namespace,
+			Kind:      w.kind,
+			Name:      w.GetName(),
+			UID:       string(w.GetUID()),
+			Container: container.Name,
+			Image:     container.Image,
+			Ports:     container.Ports,
+		}
 
-	if _, ok := f.deployments[f.name]; !ok {
-		f.view.Writef("📡  Setting up proxy on application '%s', please wait some seconds for pod to be ready...\n", deployment.Name)
+		f.mux.Lock()
+		f.workloads[w.GetUID()] = backup
+		f.mux.Unlock()
 
-		f.deployments[f.name] = &DeploymentBackup{
-			OldImage:   container.Image,
-			OldPorts:   container.Ports,
-			Deployment: &deployment,
+		if err := state.Current.AddDeployment(*backup); err != nil {
+			f.view.Writef("❌  Unable to record %s '%s' in state file: %v\n", w.kind, w.GetName(), err)
 		}
 	}
 
-	container.Image = ProxyDockerImage
+	if err := backupPodTemplate(w); err != nil {
+		return err
+	}
 
-	ports := make([]apiv1.ContainerPort, 0)
+	before := getContainerImages(w.template)
 
-	for _, port := range container.Ports {
-		if port.Name == ProxyPortName {
-			continue
-		}
+	switch f.remoteOptions.Interception {
+	case InterceptionSidecar:
+		injectProxySidecar(w.template, f.remoteOptions.InterceptedPorts, f.remoteOptions.AuthorizedKey)
+	default:
+		injectProxyReplace(w.template, index, f.remoteOptions.AuthorizedKey)
+	}
 
-		ports = append(ports, port)
+	if err := updateWorkload(ctx, f.clientSet, f.namespace, w); err != nil {
+		return fmt.Errorf("Unable to set up proxy on %s '%s': %w", w.kind, w.GetName(), err)
 	}
 
-	ports = append(ports, apiv1.ContainerPort{
-		Name:          ProxyPortName,
-		Protocol:      apiv1.ProtocolTCP,
-		ContainerPort: RemoteSSHProxyPort,
-	})
+	if err := writeAuditEntry(AuditActionIntercept, f.context, f.namespace, w, before); err != nil {
+		f.view.Writef("⚠️  %v\n", err)
+	}
+
+	return nil
+}
+
+// getWorkloads returns the workloads to deploy the proxy in: the targeted one or the ones matching the
+// selector of labels (only the first one unless all matching workloads are asked)
+func (f *Forwarder) getWorkloads(ctx context.Context) ([]*workload, error) {
+	if f.target != "" {
+		kind, name, err := parseTarget(f.target)
+		if err != nil {
+			return nil, err
+		}
+
+		if kind != TargetDeployment && kind != TargetStatefulSet && kind != TargetDaemonSet {
+			return nil, fmt.Errorf("Target '%s' is not supported by remote-forwards, please use a deployment/<name>, statefulset/<name> or daemonset/<name> target", f.target)
+		}
+
+		w, err := getWorkload(ctx, f.clientSet, f.namespace, kind, name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to find %s '%s': %w", kind, name, err)
+		}
 
-	container.Ports = ports
+		return []*workload{w}, nil
+	}
 
-	deployment.Spec.Template.Spec.Containers[0] = container
-	deployment.Spec.Template.Spec.ReadinessGates = []apiv1.PodReadinessGate{}
+	selector := f.getSelector()
 
-	_, err = deploymentsClient.Update(ctx, &deployment, metav1.UpdateOptions{})
+	workloads, err := listWorkloads(ctx, f.clientSet, f.namespace, selector)
 	if err != nil {
-		f.view.Write(err.Error())
+		return nil, err
 	}
 
-	time.Sleep(time.Duration(5 * time.Second))
+	if len(workloads) < 1 {
+		return nil, fmt.Errorf("No deployment, statefulset or daemonset available for selector '%s'", selector)
+	}
+
+	if len(workloads) > 1 && !f.remoteOptions.AllMatching {
+		if !f.hasWorkloads() {
+			f.view.Writef("⚠️  %d workloads match selector '%s', only %s will run the proxy (use all_matching to deploy it in all of them)\n", len(workloads), selector, workloads[0])
+		}
+
+		return workloads[:1], nil
+	}
 
-	// Deployment has been updated with proxy, now forward ports locally
-	return f.forwardLocal(ctx, selector)
+	return workloads, nil
 }
 
 func (f *Forwarder) getSelector() string {
@@ -321,10 +539,45 @@ func (f *Forwarder) getSelector() string {
 	return selector
 }
 
+func (f *Forwarder) setPortForwarder(portForwarder *portforward.PortForwarder) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	f.portForwarders[f.name] = portForwarder
+}
+
+// notifyReady closes the ready channel the first time a port-forward is ready
+func (f *Forwarder) notifyReady() {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	select {
+	case <-f.readyChannel:
+	default:
+		close(f.readyChannel)
+	}
+}
+
+func (f *Forwarder) hasWorkloads() bool {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	return len(f.workloads) > 0
+}
+
+func (f *Forwarder) isStopped() bool {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	return f.stopped
+}
+
 func (f *Forwarder) reset() {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
 	f.portForwarders = make(map[string]*portforward.PortForwarder, 0)
-	f.deployments = make(map[string]*DeploymentBackup, 0)
-	f.stopChannel = make(chan struct{}, 1)
+	f.workloads = make(map[types.UID]*state.Deployment, 0)
 	f.readyChannel = make(chan struct{})
 }
 
@@ -342,6 +595,35 @@ func initializeClientConfig(context string, kubeConfigPath string) (*restclient.
 	return clientConfig, nil
 }
 
+// ResolveContext returns the name of the given Kubernetes context (the current one if empty) and the
+// namespace used in it when the given one is empty
+func ResolveContext(context, namespace string) (string, string, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: getKubeConfigPath()},
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	)
+
+	if context == "" {
+		rawConfig, err := clientConfig.RawConfig()
+		if err != nil {
+			return "", "", err
+		}
+
+		context = rawConfig.CurrentContext
+	}
+
+	if namespace == "" {
+		var err error
+
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return context, namespace, nil
+}
+
 func initializeClientSet(clientConfig *restclient.Config) (*kubernetes.Clientset, error) {
 	clientSet, err := kubernetes.NewForConfig(clientConfig)
 	if err != nil {
@@ -351,11 +633,6 @@ func initializeClientSet(clientConfig *restclient.Config) (*kubernetes.Clientset
 	return clientSet, nil
 }
 
-func buildPath(request *restclient.Request) string {
-	parts := strings.Split(request.URL().Path, "/namespaces")
-	return parts[0] + "/api/v1/namespaces" + parts[1]
-}
-
 func getKubeConfigPath() string {
 	if value := os.Getenv("MONDAY_KUBE_CONFIG"); value != "" {
 		return value
@@ -9,9 +9,11 @@ import (
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	clientmocks "github.com/eko/monday/internal/test/mocks/kubernetes/client"
 	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/state"
 	"github.com/eko/monday/pkg/ui"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,6 +21,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/util/flowcontrol"
@@ -49,7 +52,7 @@ func TestNewForwarder(t *testing.T) {
 	view := ui.NewMockView(ctrl)
 
 	// When
-	forwarder, err := NewForwarder(view, config.ForwarderKubernetes, name, context, namespace, ports, labels)
+	forwarder, err := NewForwarder(view, config.ForwarderKubernetes, name, context, namespace, ports, labels, "")
 
 	// Then
 	assert.IsType(t, new(Forwarder), forwarder)
@@ -62,7 +65,7 @@ func TestNewForwarder(t *testing.T) {
 	assert.Equal(t, ports, forwarder.ports)
 
 	assert.Len(t, forwarder.portForwarders, 0)
-	assert.Len(t, forwarder.deployments, 0)
+	assert.Len(t, forwarder.workloads, 0)
 }
 
 func TestGetKubeConfigPathWhenDefault(t *testing.T) {
@@ -97,7 +100,7 @@ func TestGetForwardType(t *testing.T) {
 
 	forwarder, err := NewForwarder(view, config.ForwarderKubernetesRemote, "test-forward", "context-test", "platform", []string{"8080:8080"}, map[string]string{
 		"app": "my-test-app",
-	})
+	}, "")
 
 	// When
 	forwardType := forwarder.GetForwardType()
@@ -121,7 +124,7 @@ func TestGetSelector(t *testing.T) {
 
 	forwarder, err := NewForwarder(view, config.ForwarderKubernetesRemote, "test-forward", "context-test", "platform", []string{"8080:8080"}, map[string]string{
 		"app": "my-test-app",
-	})
+	}, "")
 
 	// When
 	selector := forwarder.getSelector()
@@ -145,7 +148,7 @@ func TestGetReadyChannel(t *testing.T) {
 
 	forwarder, err := NewForwarder(view, config.ForwarderKubernetesRemote, "test-forward", "context-test", "platform", []string{"8080:8080"}, map[string]string{
 		"app": "my-test-app",
-	})
+	}, "")
 
 	// When
 	channel := forwarder.GetReadyChannel()
@@ -167,7 +170,7 @@ func TestGetStopChannel(t *testing.T) {
 
 	forwarder, err := NewForwarder(view, config.ForwarderKubernetesRemote, "test-forward", "context-test", "platform", []string{"8080:8080"}, map[string]string{
 		"app": "my-test-app",
-	})
+	}, "")
 
 	// When
 	channel := forwarder.GetStopChannel()
@@ -190,7 +193,7 @@ func TestForwardTypeLocal(t *testing.T) {
 
 	forwarder, err := NewForwarder(view, config.ForwarderKubernetes, "test-forward", "context-test", "backend", []string{"8080:8080"}, map[string]string{
 		"app": "my-test-app",
-	})
+	}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -237,7 +240,7 @@ func TestForwardTypeLocal(t *testing.T) {
 	url, _ := url.Parse(testServer.URL)
 	rateLimiter := flowcontrol.NewTokenBucketRateLimiter(2.0, 1)
 	httpClient := &http.Client{}
-	restClientMock, _ := rest.NewRESTClient(url, "/1.0", restclient.ClientContentConfig{}, rateLimiter, httpClient)
+	restClientMock, _ := rest.NewRESTClient(url, "/1.0/api/v1", restclient.ClientContentConfig{}, rateLimiter, httpClient)
 
 	forwarder.clientSet = clientSetMock
 	forwarder.restClient = restClientMock
@@ -251,6 +254,8 @@ func TestForwardTypeLocal(t *testing.T) {
 
 func TestForwardTypeRemote(t *testing.T) {
 	// Given
+	useTempAuditLog(t)
+
 	ctx := context.Background()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -259,16 +264,17 @@ func TestForwardTypeRemote(t *testing.T) {
 	defer os.Remove(defaultKubeConfigPath)
 
 	view := ui.NewMockView(ctrl)
-	view.EXPECT().Writef("📡  Setting up proxy on application '%s', please wait some seconds for pod to be ready...\n", "my-remote-app-deployment")
+	view.EXPECT().Writef("📡  Setting up proxy on application '%s', please wait for the rollout to be complete...\n", "my-remote-app-deployment")
+	view.EXPECT().Writef("✅  Proxy is ready on application '%s'\n", "my-remote-app-deployment")
 
 	forwarder, err := NewForwarder(view, config.ForwarderKubernetesRemote, "test-remote-forward", "context-test", "backend", []string{"8080:8080"}, map[string]string{
 		"app": "my-remote-app",
-	})
+	}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Define deployment & container mock
+	// Define deployment & container, the rollout of the proxy being already complete
 	containerMock := corev1.Container{
 		Image: "acme.tld/my-remote-app",
 		Ports: []corev1.ContainerPort{
@@ -280,55 +286,21 @@ func TestForwardTypeRemote(t *testing.T) {
 		},
 	}
 
-	deploymentMock := appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "my-remote-app-deployment",
-		},
-		Spec: appsv1.DeploymentSpec{
-			Template: corev1.PodTemplateSpec{
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{containerMock},
-				},
-			},
-		},
-	}
-
-	// Mock Kubernetes Go client calls for retrieving deployment
-	deploymentInterface := &clientmocks.DeploymentInterface{}
-	deploymentInterface.On("List", ctx, metav1.ListOptions{LabelSelector: "app=my-remote-app"}).
-		Return(&appsv1.DeploymentList{
-			Items: []appsv1.Deployment{
-				deploymentMock,
-			},
-		}, nil)
-
-	deploymentInterface.
-		On("Update", ctx, mock.AnythingOfType("*v1.Deployment"), metav1.UpdateOptions{}).
-		Return(nil, nil)
-
-	appsV1Interface := &clientmocks.AppsV1Interface{}
-	appsV1Interface.On("Deployments", "backend").
-		Return(deploymentInterface)
+	deploymentMock := newRolledOutDeployment("my-remote-app-deployment", "my-remote-app")
+	deploymentMock.Spec.Template.Spec.Containers = []corev1.Container{containerMock}
 
-	// Local forward then...
-	// Mock Kubernetes Go client calls for retrieving pods
-	podInterface := &clientmocks.PodInterface{}
-	podInterface.On("List", ctx, metav1.ListOptions{LabelSelector: "app=my-remote-app"}).
-		Return(&corev1.PodList{
-			Items: []corev1.Pod{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "my-test-app-bd4sk",
-					},
-				},
-			},
-		}, nil)
+	podMock := newPod("my-remote-app-5d8f7b9c4-bd4sk", corev1.PodRunning, true, time.Now())
+	podMock.Labels = map[string]string{"app": "my-remote-app"}
+	podMock.Spec.Containers[0].Image = ProxyDockerImage
 
-	coreV1Interface := &clientmocks.CoreV1Interface{}
-	coreV1Interface.On("Pods", "backend").Return(podInterface)
+	clientSet := fake.NewSimpleClientset(deploymentMock, podMock)
 
 	// Mock Kubernetes Rest client
+	requests := make(chan string, 2)
+
 	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests <- req.Method + " " + req.URL.Path
+
 		res.WriteHeader(http.StatusOK)
 		res.Write([]byte("ok, port forward is asked"))
 	}))
@@ -336,31 +308,33 @@ func TestForwardTypeRemote(t *testing.T) {
 	url, _ := url.Parse(testServer.URL)
 	rateLimiter := flowcontrol.NewTokenBucketRateLimiter(2.0, 1)
 	httpClient := &http.Client{}
-	restClientMock, _ := rest.NewRESTClient(url, "/1.0", restclient.ClientContentConfig{}, rateLimiter, httpClient)
+	restClientMock, _ := rest.NewRESTClient(url, "/1.0/api/v1", restclient.ClientContentConfig{}, rateLimiter, httpClient)
 
-	// ClientSet and ClientRest
-	clientSetMock := &clientmocks.Interface{}
-	clientSetMock.On("AppsV1").
-		Return(appsV1Interface)
-	clientSetMock.On("CoreV1").
-		Return(coreV1Interface)
-
-	// Replace mocked properties
-	forwarder.clientSet = clientSetMock
+	// Replace client properties
+	forwarder.clientSet = clientSet
 	forwarder.restClient = restClientMock
 
 	// When
 	err = forwarder.Forward(ctx)
 
 	// Then
-	assert.Equal(t, errors.New("No runnning pod available for selector 'app=my-remote-app'"), err)
+	assert.Equal(t, errors.New("error upgrading connection: unable to upgrade connection: ok, port forward is asked"), err)
+
+	// Port-forward has been asked to the proxy pod over WebSocket first, then over SPDY
+	assert.Equal(t, "GET /1.0/api/v1/namespaces/backend/pods/my-remote-app-5d8f7b9c4-bd4sk/portforward", <-requests)
+	assert.Equal(t, "POST /1.0/api/v1/namespaces/backend/pods/my-remote-app-5d8f7b9c4-bd4sk/portforward", <-requests)
 
-	if deploy, ok := forwarder.deployments["test-remote-forward"]; ok {
-		assert.Equal(t, deploy.OldImage, "acme.tld/my-remote-app")
-		assert.Equal(t, deploy.Deployment.Spec.Template.Spec.Containers[0].Image, "ekofr/monday-proxy")
+	if backup, ok := forwarder.workloads[deploymentMock.UID]; ok {
+		assert.Equal(t, TargetDeployment, backup.Kind)
+		assert.Equal(t, "acme.tld/my-remote-app", backup.Image)
 	} else {
 		t.Fatal("Cannot retrieve backuped deployment image when doing remote-forward")
 	}
+
+	deployment, err := clientSet.AppsV1().Deployments("backend").Get(ctx, "my-remote-app-deployment", metav1.GetOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, ProxyDockerImage, deployment.Spec.Template.Spec.Containers[0].Image)
 }
 
 // Initializes a Kubernetes configuration for test environment
@@ -393,3 +367,100 @@ func initKubeConfig(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRestoreDeployment(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	ctx := context.Background()
+
+	deploymentMock := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-remote-app-deployment",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Image: ProxyDockerImage},
+					},
+				},
+			},
+		},
+	}
+
+	backup := state.Deployment{
+		Context:   "context-test",
+		Namespace: "backend",
+		Name:      "my-remote-app-deployment",
+		Image:     "acme.tld/my-remote-app",
+		Ports: []corev1.ContainerPort{
+			{Name: "http", ContainerPort: 8080},
+		},
+	}
+
+	deploymentInterface := &clientmocks.DeploymentInterface{}
+	deploymentInterface.On("Get", ctx, "my-remote-app-deployment", metav1.GetOptions{}).
+		Return(deploymentMock, nil)
+	deploymentInterface.On("Update", ctx, mock.MatchedBy(func(deployment *appsv1.Deployment) bool {
+		container := deployment.Spec.Template.Spec.Containers[0]
+		return container.Image == "acme.tld/my-remote-app" && len(container.Ports) == 1
+	}), metav1.UpdateOptions{}).
+		Return(nil, nil)
+
+	appsV1Interface := &clientmocks.AppsV1Interface{}
+	appsV1Interface.On("Deployments", "backend").Return(deploymentInterface)
+
+	clientSetMock := &clientmocks.Interface{}
+	clientSetMock.On("AppsV1").Return(appsV1Interface)
+
+	// When
+	err := restoreDeployment(ctx, clientSetMock, backup)
+
+	// Then
+	assert.Nil(t, err)
+	deploymentInterface.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestRestoreDeploymentWhenAlreadyRestored(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	ctx := context.Background()
+
+	deploymentMock := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-remote-app-deployment",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Image: "acme.tld/my-remote-app"},
+					},
+				},
+			},
+		},
+	}
+
+	deploymentInterface := &clientmocks.DeploymentInterface{}
+	deploymentInterface.On("Get", ctx, "my-remote-app-deployment", metav1.GetOptions{}).
+		Return(deploymentMock, nil)
+
+	appsV1Interface := &clientmocks.AppsV1Interface{}
+	appsV1Interface.On("Deployments", "backend").Return(deploymentInterface)
+
+	clientSetMock := &clientmocks.Interface{}
+	clientSetMock.On("AppsV1").Return(appsV1Interface)
+
+	// When
+	err := restoreDeployment(ctx, clientSetMock, state.Deployment{
+		Namespace: "backend",
+		Name:      "my-remote-app-deployment",
+		Image:     "acme.tld/my-remote-app",
+	})
+
+	// Then
+	assert.Nil(t, err)
+	deploymentInterface.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// InterceptionReplace replaces the application container image with the proxy one
+	InterceptionReplace = "replace"
+
+	// InterceptionSidecar adds the proxy as a sidecar container and redirects the traffic of
+	// the forwarded ports to it, keeping the application container untouched
+	InterceptionSidecar = "sidecar"
+
+	// OriginalSpecAnnotation is the workload annotation storing the original pod template
+	// while the proxy is deployed, so that it can be restored exactly
+	OriginalSpecAnnotation = "monday/original-spec"
+
+	// ProxySidecarName is the name of the proxy container added in sidecar interception mode
+	ProxySidecarName = "monday-proxy"
+
+	// SidecarBasePort is the first port listened by the proxy sidecar for the forwarded ports
+	SidecarBasePort = 15100
+
+	// RedirectPortsEnv is the environment variable giving the proxy sidecar the ports to redirect,
+	// as a comma-separated list of "<port>:<sidecar port>"
+	RedirectPortsEnv = "MONDAY_REDIRECT_PORTS"
+
+	// AuthorizedKeyEnv is the environment variable giving the proxy the public key of the session,
+	// which is the only one allowed to connect
+	AuthorizedKeyEnv = "MONDAY_AUTHORIZED_KEY"
+)
+
+// GetSidecarPort returns the port listened by the proxy sidecar for the forwarded port at the given index
+func GetSidecarPort(index int) int {
+	return SidecarBasePort + index
+}
+
+func isInterceptionSupported(interception string) bool {
+	return interception == "" || interception == InterceptionReplace || interception == InterceptionSidecar
+}
+
+// backupPodTemplate stores the workload pod template in an annotation, unless a previous
+// interception already did: in that case, the annotation already contains the original one
+func backupPodTemplate(w *workload) error {
+	annotations := w.GetAnnotations()
+
+	if _, ok := annotations[OriginalSpecAnnotation]; ok {
+		return nil
+	}
+
+	content, err := json.Marshal(w.template)
+	if err != nil {
+		return fmt.Errorf("Unable to backup %s '%s' specification: %w", w.kind, w.GetName(), err)
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[OriginalSpecAnnotation] = string(content)
+	w.SetAnnotations(annotations)
+
+	return nil
+}
+
+// restorePodTemplate puts back the pod template stored in annotation and returns false if there is none
+func restorePodTemplate(w *workload) (bool, error) {
+	annotations := w.GetAnnotations()
+
+	content, ok := annotations[OriginalSpecAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	var template apiv1.PodTemplateSpec
+	if err := json.Unmarshal([]byte(content), &template); err != nil {
+		return false, fmt.Errorf("Unable to read %s '%s' original specification: %w", w.kind, w.GetName(), err)
+	}
+
+	*w.template = template
+
+	delete(annotations, OriginalSpecAnnotation)
+	w.SetAnnotations(annotations)
+
+	return true, nil
+}
+
+// injectProxyReplace replaces the image of the container at the given index with the proxy image
+func injectProxyReplace(template *apiv1.PodTemplateSpec, index int, authorizedKey string) {
+	container := template.Spec.Containers[index]
+	container.Image = ProxyDockerImage
+	container.Env = setEnvVar(container.Env, AuthorizedKeyEnv, authorizedKey)
+
+	// The proxy has its own entrypoint and cannot answer the application probes, so pods would never be ready
+	container.Command = nil
+	container.Args = nil
+	container.ReadinessProbe = nil
+	container.LivenessProbe = nil
+	container.StartupProbe = nil
+
+	ports := make([]apiv1.ContainerPort, 0)
+
+	for _, port := range container.Ports {
+		if port.Name == ProxyPortName {
+			continue
+		}
+
+		ports = append(ports, port)
+	}
+
+	ports = append(ports, apiv1.ContainerPort{
+		Name:          ProxyPortName,
+		Protocol:      apiv1.ProtocolTCP,
+		ContainerPort: RemoteSSHProxyPort,
+	})
+
+	container.Ports = ports
+
+	template.Spec.Containers[index] = container
+	template.Spec.ReadinessGates = []apiv1.PodReadinessGate{}
+}
+
+// injectProxySidecar adds the proxy container next to the application ones. Traffic received by the pod
+// on the given ports is redirected to the sidecar, where SSH remote-forwards listen.
+func injectProxySidecar(template *apiv1.PodTemplateSpec, ports []string, authorizedKey string) {
+	redirects := make([]string, 0, len(ports))
+	for index, port := range ports {
+		redirects = append(redirects, fmt.Sprintf("%s:%d", port, GetSidecarPort(index)))
+	}
+
+	sidecar := apiv1.Container{
+		Name:  ProxySidecarName,
+		Image: ProxyDockerImage,
+		Ports: []apiv1.ContainerPort{
+			{
+				Name:          ProxyPortName,
+				Protocol:      apiv1.ProtocolTCP,
+				ContainerPort: RemoteSSHProxyPort,
+			},
+		},
+		Env: []apiv1.EnvVar{
+			{Name: RedirectPortsEnv, Value: strings.Join(redirects, ",")},
+			{Name: AuthorizedKeyEnv, Value: authorizedKey},
+		},
+		// Redirecting the traffic requires to update the pod iptables rules
+		SecurityContext: &apiv1.SecurityContext{
+			Capabilities: &apiv1.Capabilities{
+				Add: []apiv1.Capability{"NET_ADMIN"},
+			},
+		},
+	}
+
+	containers := make([]apiv1.Container, 0, len(template.Spec.Containers)+1)
+
+	for _, container := range template.Spec.Containers {
+		if container.Name == ProxySidecarName {
+			continue
+		}
+
+		containers = append(containers, container)
+	}
+
+	template.Spec.Containers = append(containers, sidecar)
+}
+
+// setEnvVar sets the value of the given environment variable, replacing the existing one if any
+func setEnvVar(envs []apiv1.EnvVar, name, value string) []apiv1.EnvVar {
+	result := make([]apiv1.EnvVar, 0, len(envs)+1)
+
+	for _, env := range envs {
+		if env.Name == name {
+			continue
+		}
+
+		result = append(result, env)
+	}
+
+	return append(result, apiv1.EnvVar{Name: name, Value: value})
+}
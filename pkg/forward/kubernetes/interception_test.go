@@ -0,0 +1,262 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eko/monday/pkg/state"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testAuthorizedKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHd0sZGq1mUd6v0Bf8tJxQ0N8wQ9xwYb3kT5m1dR6a2F"
+
+func TestInjectProxyReplace(t *testing.T) {
+	// Given
+	deployment := newInterceptionDeployment()
+	deployment.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{
+		{Name: "APP_ENV", Value: "staging"},
+		{Name: AuthorizedKeyEnv, Value: "ssh-ed25519 previous-session-key"},
+	}
+
+	// When
+	injectProxyReplace(&deployment.Spec.Template, 0, testAuthorizedKey)
+
+	// Then
+	container := deployment.Spec.Template.Spec.Containers[0]
+
+	assert.Equal(t, ProxyDockerImage, container.Image)
+	assert.Nil(t, container.ReadinessProbe)
+	assert.Empty(t, deployment.Spec.Template.Spec.ReadinessGates)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "APP_ENV", Value: "staging"},
+		{Name: AuthorizedKeyEnv, Value: testAuthorizedKey},
+	}, container.Env)
+}
+
+func TestInjectProxySidecar(t *testing.T) {
+	// Given
+	deployment := newInterceptionDeployment()
+	original := deployment.Spec.Template.DeepCopy()
+
+	// When
+	err := backupPodTemplate(newDeploymentWorkload(deployment))
+	injectProxySidecar(&deployment.Spec.Template, []string{"8080", "8001"}, testAuthorizedKey)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Contains(t, deployment.Annotations, OriginalSpecAnnotation)
+
+	containers := deployment.Spec.Template.Spec.Containers
+
+	assert.Len(t, containers, 2)
+	assert.Equal(t, original.Spec.Containers[0], containers[0])
+	assert.Equal(t, original.Spec.ReadinessGates, deployment.Spec.Template.Spec.ReadinessGates)
+
+	assert.Equal(t, ProxySidecarName, containers[1].Name)
+	assert.Equal(t, ProxyDockerImage, containers[1].Image)
+	assert.Equal(t, int32(RemoteSSHProxyPort), containers[1].Ports[0].ContainerPort)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: RedirectPortsEnv, Value: "8080:15100,8001:15101"},
+		{Name: AuthorizedKeyEnv, Value: testAuthorizedKey},
+	}, containers[1].Env)
+	assert.Equal(t, []corev1.Capability{"NET_ADMIN"}, containers[1].SecurityContext.Capabilities.Add)
+}
+
+func TestInjectProxySidecarWhenAlreadyInjected(t *testing.T) {
+	// Given
+	deployment := newInterceptionDeployment()
+
+	backupPodTemplate(newDeploymentWorkload(deployment))
+	injectProxySidecar(&deployment.Spec.Template, []string{"8080"}, testAuthorizedKey)
+
+	annotation := deployment.Annotations[OriginalSpecAnnotation]
+
+	// When
+	err := backupPodTemplate(newDeploymentWorkload(deployment))
+	injectProxySidecar(&deployment.Spec.Template, []string{"8080"}, testAuthorizedKey)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, annotation, deployment.Annotations[OriginalSpecAnnotation])
+	assert.Len(t, deployment.Spec.Template.Spec.Containers, 2)
+}
+
+func TestStopRestoresOriginalSpec(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	ctx := context.Background()
+
+	deployment := newInterceptionDeployment()
+	original := deployment.Spec.Template.DeepCopy()
+
+	backupPodTemplate(newDeploymentWorkload(deployment))
+	injectProxyReplace(&deployment.Spec.Template, 0, testAuthorizedKey)
+
+	clientSet := fake.NewSimpleClientset(deployment)
+
+	forwarder := &Forwarder{
+		name:      "test-remote-forward",
+		namespace: "backend",
+		target:    "deployment/my-remote-app",
+		clientSet: clientSet,
+		workloads: map[types.UID]*state.Deployment{
+			deployment.UID: {Namespace: "backend", Kind: TargetDeployment, Name: "my-remote-app", UID: string(deployment.UID)},
+		},
+		stopChannel: make(chan struct{}),
+	}
+
+	// When
+	err := forwarder.Stop(ctx)
+
+	// Then
+	assert.Nil(t, err)
+
+	restored, err := clientSet.AppsV1().Deployments("backend").Get(ctx, "my-remote-app", metav1.GetOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, *original, restored.Spec.Template)
+	assert.NotContains(t, restored.Annotations, OriginalSpecAnnotation)
+}
+
+func TestRestoreDeploymentFromAnnotation(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	ctx := context.Background()
+
+	deployment := newInterceptionDeployment()
+	original := deployment.Spec.Template.DeepCopy()
+
+	backupPodTemplate(newDeploymentWorkload(deployment))
+	injectProxySidecar(&deployment.Spec.Template, []string{"8080"}, testAuthorizedKey)
+
+	clientSet := fake.NewSimpleClientset(deployment)
+
+	// When
+	err := restoreDeployment(ctx, clientSet, state.Deployment{
+		Context:   "context-test",
+		Namespace: "backend",
+		Name:      "my-remote-app",
+		Image:     "acme.tld/my-remote-app",
+	})
+
+	// Then
+	assert.Nil(t, err)
+
+	restored, err := clientSet.AppsV1().Deployments("backend").Get(ctx, "my-remote-app", metav1.GetOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, *original, restored.Spec.Template)
+	assert.NotContains(t, restored.Annotations, OriginalSpecAnnotation)
+}
+
+func TestRestoreDeploymentWhenRecreated(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	ctx := context.Background()
+
+	deployment := newInterceptionDeployment()
+
+	backupPodTemplate(newDeploymentWorkload(deployment))
+	injectProxyReplace(&deployment.Spec.Template, 0, testAuthorizedKey)
+
+	clientSet := fake.NewSimpleClientset(deployment)
+
+	// When
+	err := restoreDeployment(ctx, clientSet, state.Deployment{
+		Context:   "context-test",
+		Namespace: "backend",
+		Kind:      TargetDeployment,
+		Name:      "my-remote-app",
+		UID:       "0a9b8c7d-6e5f-4a3b-2c1d-0e9f8a7b6c5d",
+		Image:     "acme.tld/my-remote-app",
+	})
+
+	// Then
+	assert.Nil(t, err)
+
+	untouched, err := clientSet.AppsV1().Deployments("backend").Get(ctx, "my-remote-app", metav1.GetOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, deployment.Spec.Template, untouched.Spec.Template)
+	assert.Contains(t, untouched.Annotations, OriginalSpecAnnotation)
+}
+
+func TestRestoreDeploymentWhenStatefulSetWithoutAnnotation(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	ctx := context.Background()
+
+	statefulSet := newStatefulSet("my-remote-app", "my-remote-app")
+	statefulSet.Spec.Template.Spec.Containers = []corev1.Container{
+		{Name: "envoy", Image: "envoyproxy/envoy"},
+		{Name: "app", Image: ProxyDockerImage},
+	}
+
+	clientSet := fake.NewSimpleClientset(statefulSet)
+
+	// When
+	err := restoreDeployment(ctx, clientSet, state.Deployment{
+		Context:   "context-test",
+		Namespace: "backend",
+		Kind:      TargetStatefulSet,
+		Name:      "my-remote-app",
+		UID:       string(statefulSet.UID),
+		Container: "app",
+		Image:     "acme.tld/my-remote-app",
+	})
+
+	// Then
+	assert.Nil(t, err)
+
+	restored, err := clientSet.AppsV1().StatefulSets("backend").Get(ctx, "my-remote-app", metav1.GetOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "envoyproxy/envoy", restored.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "acme.tld/my-remote-app", restored.Spec.Template.Spec.Containers[1].Image)
+}
+
+func newInterceptionDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-remote-app",
+			Namespace: "backend",
+			UID:       "1f3e5d7c-9b2a-4c6e-8d0f-2a4c6e8b0d1f",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-remote-app"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "my-remote-app"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "app",
+							Image: "acme.tld/my-remote-app",
+							Ports: []corev1.ContainerPort{
+								{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{Path: "/health"},
+								},
+							},
+						},
+					},
+					ReadinessGates: []corev1.PodReadinessGate{
+						{ConditionType: "acme.tld/load-balancer-ready"},
+					},
+				},
+			},
+		},
+	}
+}
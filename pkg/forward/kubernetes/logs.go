@@ -0,0 +1,61 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/eko/monday/pkg/log"
+	"github.com/eko/monday/pkg/ui"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// LogsOptions are the options used to follow the logs of the pod serving the forward
+type LogsOptions struct {
+	// Enabled follows the logs of the pod currently being port-forwarded
+	Enabled bool
+
+	// Container is the name of the container to follow the logs of, the first one if empty
+	Container string
+
+	// Tail is the number of lines of previous logs to display, all of them if zero
+	Tail int64
+
+	// View is the view the logs are written to
+	View ui.View
+}
+
+// SetLogs sets whether and how the logs of the pod serving the forward are followed
+func (f *Forwarder) SetLogs(options LogsOptions) {
+	f.logsOptions = options
+}
+
+// followPodLogs streams the logs of the given pod until the context is done (the forward switched to
+// another pod or has been stopped)
+func (f *Forwarder) followPodLogs(ctx context.Context, pod *apiv1.Pod) {
+	options := &apiv1.PodLogOptions{
+		Follow:    true,
+		Container: f.logsOptions.Container,
+	}
+
+	if f.logsOptions.Tail > 0 {
+		tail := f.logsOptions.Tail
+		options.TailLines = &tail
+	}
+
+	stream, err := f.clientSet.CoreV1().Pods(f.namespace).GetLogs(pod.Name, options).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			f.view.Writef("⚠️  Unable to follow logs of pod '%s': %v\n", pod.Name, err)
+		}
+		return
+	}
+	defer stream.Close()
+
+	streamer := log.NewStreamer(log.StdOut, f.name, f.logsOptions.View)
+	defer streamer.Close()
+
+	if _, err := io.Copy(streamer, stream); err != nil && ctx.Err() == nil && !errors.Is(err, context.Canceled) {
+		f.view.Writef("⚠️  Logs of pod '%s' are not followed anymore: %v\n", pod.Name, err)
+	}
+}
@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestFollowPodLogs(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	logsView := ui.NewMockView(ctrl)
+	logsView.EXPECT().Write(gomock.Any()).Do(func(str string) {
+		assert.Contains(t, str, "test-forward")
+		assert.True(t, strings.HasSuffix(str, " fake logs\n"))
+	})
+
+	var logOptions *corev1.PodLogOptions
+
+	clientSet := fake.NewSimpleClientset()
+	clientSet.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "log" {
+			logOptions = action.(k8stesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+		}
+		return false, nil, nil
+	})
+
+	forwarder := &Forwarder{
+		view:      view,
+		name:      "test-forward",
+		namespace: "backend",
+		clientSet: clientSet,
+	}
+
+	forwarder.SetLogs(LogsOptions{
+		Enabled:   true,
+		Container: "app",
+		Tail:      50,
+		View:      logsView,
+	})
+
+	// When
+	forwarder.followPodLogs(ctx, newPod("my-app-a", corev1.PodRunning, true, time.Now()))
+
+	// Then
+	assert.NotNil(t, logOptions)
+	assert.True(t, logOptions.Follow)
+	assert.Equal(t, "app", logOptions.Container)
+	assert.Equal(t, int64(50), *logOptions.TailLines)
+}
+
+func TestFollowPodLogsWithoutTail(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	logsView := ui.NewMockView(ctrl)
+	logsView.EXPECT().Write(gomock.Any())
+
+	var logOptions *corev1.PodLogOptions
+
+	clientSet := fake.NewSimpleClientset()
+	clientSet.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "log" {
+			logOptions = action.(k8stesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+		}
+		return false, nil, nil
+	})
+
+	forwarder := &Forwarder{
+		view:      view,
+		name:      "test-forward",
+		namespace: "backend",
+		clientSet: clientSet,
+	}
+
+	forwarder.SetLogs(LogsOptions{Enabled: true, View: logsView})
+
+	// When
+	forwarder.followPodLogs(ctx, newPod("my-app-a", corev1.PodRunning, true, time.Now()))
+
+	// Then
+	assert.NotNil(t, logOptions)
+	assert.Equal(t, "", logOptions.Container)
+	assert.Nil(t, logOptions.TailLines)
+}
@@ -0,0 +1,107 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eko/monday/pkg/config"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// permission is a verb allowed on a Kubernetes resource (and optionally one of its subresources)
+type permission struct {
+	group       string
+	resource    string
+	subresource string
+	verb        string
+}
+
+func (p permission) String() string {
+	resource := p.resource
+	if p.subresource != "" {
+		resource += "/" + p.subresource
+	}
+
+	return p.verb + " " + resource
+}
+
+var (
+	workloadResources = map[string]string{
+		TargetDeployment:  "deployments",
+		TargetStatefulSet: "statefulsets",
+		TargetDaemonSet:   "daemonsets",
+	}
+)
+
+// getRequiredPermissions returns the permissions needed by the forward: port-forward to a pod and,
+// in case of remote-forward, find the workloads in which the proxy is deployed, update them and wait
+// for their rollout. Without target, any deployment, statefulset or daemonset can match the selector.
+func (f *Forwarder) getRequiredPermissions() []permission {
+	permissions := []permission{
+		{resource: "pods", verb: "list"},
+		{resource: "pods", subresource: "portforward", verb: "create"},
+	}
+
+	if f.forwardType != config.ForwarderKubernetesRemote {
+		return permissions
+	}
+
+	kinds := []string{TargetDeployment, TargetStatefulSet, TargetDaemonSet}
+
+	if f.target != "" {
+		if kind, _, err := parseTarget(f.target); err == nil && workloadResources[kind] != "" {
+			kinds = []string{kind}
+		}
+	} else {
+		for _, kind := range kinds {
+			permissions = append(permissions, permission{group: "apps", resource: workloadResources[kind], verb: "list"})
+		}
+	}
+
+	for _, kind := range kinds {
+		for _, verb := range []string{"get", "watch", "update"} {
+			permissions = append(permissions, permission{group: "apps", resource: workloadResources[kind], verb: verb})
+		}
+	}
+
+	return permissions
+}
+
+// CheckPermissions ensures the current Kubernetes user is allowed to run the forward, so that missing
+// permissions are reported up front instead of making each connection attempt fail
+func (f *Forwarder) CheckPermissions(ctx context.Context) error {
+	missing := make([]string, 0)
+
+	for _, permission := range f.getRequiredPermissions() {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   f.namespace,
+					Group:       permission.group,
+					Resource:    permission.resource,
+					Subresource: permission.subresource,
+					Verb:        permission.verb,
+				},
+			},
+		}
+
+		result, err := f.clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			// Permissions could not be checked: let the forward tell by itself
+			f.view.Writef("⚠️  Unable to check Kubernetes permissions of forward '%s': %v\n", f.name, err)
+			return nil
+		}
+
+		if !result.Status.Allowed {
+			missing = append(missing, permission.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("Forward '%s' is missing Kubernetes permissions in namespace '%s': %s", f.name, f.namespace, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	forwarder := newPermissionForwarder(t, config.ForwarderKubernetesRemote, "statefulset/my-app", "create pods/portforward", "list pods", "get statefulsets", "watch statefulsets", "update statefulsets")
+
+	// When
+	err := forwarder.CheckPermissions(ctx)
+
+	// Then
+	assert.Nil(t, err)
+}
+
+func TestCheckPermissionsWhenMissing(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	forwarder := newPermissionForwarder(t, config.ForwarderKubernetesRemote, "", "list pods", "create pods/portforward", "list deployments", "get deployments", "watch deployments", "update deployments")
+
+	// When
+	err := forwarder.CheckPermissions(ctx)
+
+	// Then
+	assert.EqualError(t, err, "Forward 'test-forward' is missing Kubernetes permissions in namespace 'backend': list statefulsets, list daemonsets, get statefulsets, watch statefulsets, update statefulsets, get daemonsets, watch daemonsets, update daemonsets")
+}
+
+func TestCheckPermissionsWhenTargetMissesRollout(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	forwarder := newPermissionForwarder(t, config.ForwarderKubernetesRemote, "deployment/my-app", "create pods/portforward", "list pods", "get deployments", "update deployments")
+
+	// When
+	err := forwarder.CheckPermissions(ctx)
+
+	// Then
+	assert.EqualError(t, err, "Forward 'test-forward' is missing Kubernetes permissions in namespace 'backend': watch deployments")
+}
+
+func TestCheckPermissionsWhenLocalForward(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	forwarder := newPermissionForwarder(t, config.ForwarderKubernetes, "deployment/my-app", "create pods/portforward", "list pods")
+
+	// When
+	err := forwarder.CheckPermissions(ctx)
+
+	// Then
+	assert.Nil(t, err)
+}
+
+func TestCheckPermissionsWhenReviewFails(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⚠️  Unable to check Kubernetes permissions of forward '%s': %v\n", "test-forward", errors.New("server unavailable"))
+
+	clientSet := fake.NewSimpleClientset()
+	clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("server unavailable")
+	})
+
+	forwarder := &Forwarder{
+		view:        view,
+		forwardType: config.ForwarderKubernetes,
+		name:        "test-forward",
+		namespace:   "backend",
+		clientSet:   clientSet,
+	}
+
+	// When
+	err := forwarder.CheckPermissions(ctx)
+
+	// Then
+	assert.Nil(t, err)
+}
+
+// newPermissionForwarder returns a forwarder whose Kubernetes user is only allowed the given permissions
+func newPermissionForwarder(t *testing.T, forwardType, target string, allowed ...string) *Forwarder {
+	clientSet := fake.NewSimpleClientset()
+	clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+
+		assert.Equal(t, "backend", attributes.Namespace)
+
+		requested := permission{
+			group:       attributes.Group,
+			resource:    attributes.Resource,
+			subresource: attributes.Subresource,
+			verb:        attributes.Verb,
+		}
+
+		for _, permission := range allowed {
+			if permission == requested.String() {
+				review.Status.Allowed = true
+			}
+		}
+
+		return true, review, nil
+	})
+
+	return &Forwarder{
+		forwardType: forwardType,
+		name:        "test-forward",
+		namespace:   "backend",
+		target:      target,
+		clientSet:   clientSet,
+	}
+}
@@ -0,0 +1,212 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var (
+	// rolloutTimeout is the maximum duration to wait for the proxy to be rolled out
+	rolloutTimeout = 5 * time.Minute
+
+	// rolloutCheckInterval is the delay between two rollout checks, in addition to the workload watch events
+	rolloutCheckInterval = 2 * time.Second
+
+	errStopped = errors.New("forwarder has been stopped")
+)
+
+// waitForRollout waits for the given workload rollout to be complete, the same way kubectl rollout status
+// does, and for at least one of its pods running the proxy to be ready
+func (f *Forwarder) waitForRollout(ctx context.Context, w *workload) error {
+	ctx, cancel := context.WithTimeout(ctx, rolloutTimeout)
+	defer cancel()
+
+	// Workload events make the rollout checked sooner, checks are also done periodically in case watch is not available
+	var events <-chan watch.Event
+
+	if watcher, err := f.watchWorkload(ctx, w); err == nil {
+		defer watcher.Stop()
+		events = watcher.ResultChan()
+	}
+
+	ticker := time.NewTicker(rolloutCheckInterval)
+	defer ticker.Stop()
+
+	lastStatus := ""
+
+	for {
+		status, err := f.getRolloutStatus(ctx, w.kind, w.GetName(), w.GetGeneration())
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		if err == nil && status == "" {
+			return nil
+		}
+
+		if status != "" && status != lastStatus {
+			f.view.Writef("⏳  Rollout of %s '%s': %s...\n", w.kind, w.GetName(), status)
+			lastStatus = status
+		}
+
+		select {
+		case <-f.stopChannel:
+			return errStopped
+
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("Timeout after %v while waiting for the rollout of %s '%s' (%s)", rolloutTimeout, w.kind, w.GetName(), lastStatus)
+			}
+			return ctx.Err()
+
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+			}
+
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Forwarder) watchWorkload(ctx context.Context, w *workload) (watch.Interface, error) {
+	options := metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", w.GetName()).String(),
+	}
+
+	switch w.kind {
+	case TargetStatefulSet:
+		return f.clientSet.AppsV1().StatefulSets(f.namespace).Watch(ctx, options)
+	case TargetDaemonSet:
+		return f.clientSet.AppsV1().DaemonSets(f.namespace).Watch(ctx, options)
+	}
+
+	return f.clientSet.AppsV1().Deployments(f.namespace).Watch(ctx, options)
+}
+
+// getRolloutStatus returns the rollout progress of the workload, which is empty once the rollout is complete
+func (f *Forwarder) getRolloutStatus(ctx context.Context, kind, name string, generation int64) (string, error) {
+	w, err := getWorkload(ctx, f.clientSet, f.namespace, kind, name)
+	if err != nil {
+		return "", fmt.Errorf("Unable to retrieve %s '%s' rollout status: %w", kind, name, err)
+	}
+
+	var status string
+
+	switch w.kind {
+	case TargetStatefulSet:
+		status, err = getStatefulSetRolloutStatus(w.statefulSet, generation)
+	case TargetDaemonSet:
+		status, err = getDaemonSetRolloutStatus(w.daemonSet, generation)
+	default:
+		status, err = getDeploymentRolloutStatus(w.deployment, generation)
+	}
+
+	if err != nil || status != "" {
+		return status, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(w.selector)
+	if err != nil {
+		return "", fmt.Errorf("Invalid selector on %s '%s': %w", kind, name, err)
+	}
+
+	filter := &podFilter{selector: selector, proxy: true}
+
+	pods, err := f.clientSet.CoreV1().Pods(f.namespace).List(ctx, filter.listOptions())
+	if err != nil {
+		return "", fmt.Errorf("Unable to retrieve %s '%s' pods: %w", kind, name, err)
+	}
+
+	for i := range pods.Items {
+		if filter.matches(&pods.Items[i]) && isPodHealthy(&pods.Items[i]) {
+			return "", nil
+		}
+	}
+
+	return "waiting for the proxy pods to be ready", nil
+}
+
+func getDeploymentRolloutStatus(deployment *appsv1.Deployment, generation int64) (string, error) {
+	if deployment.Status.ObservedGeneration < generation {
+		return "waiting for the deployment update to be observed", nil
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return "", fmt.Errorf("Rollout of deployment '%s' exceeded its progress deadline: %s", deployment.Name, condition.Message)
+		}
+	}
+
+	var replicas int32 = 1
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+
+	switch {
+	case status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("%d out of %d new replicas have been updated", status.UpdatedReplicas, replicas), nil
+	case status.Replicas > status.UpdatedReplicas:
+		return fmt.Sprintf("%d old replicas are pending termination", status.Replicas-status.UpdatedReplicas), nil
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return fmt.Sprintf("%d of %d updated replicas are available", status.AvailableReplicas, status.UpdatedReplicas), nil
+	}
+
+	return "", nil
+}
+
+func getStatefulSetRolloutStatus(statefulSet *appsv1.StatefulSet, generation int64) (string, error) {
+	if statefulSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return "", fmt.Errorf("Statefulset '%s' uses the OnDelete update strategy, its pods have to be deleted to run the proxy", statefulSet.Name)
+	}
+
+	if statefulSet.Status.ObservedGeneration < generation {
+		return "waiting for the statefulset update to be observed", nil
+	}
+
+	var replicas int32 = 1
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	status := statefulSet.Status
+
+	switch {
+	case status.ReadyReplicas < replicas:
+		return fmt.Sprintf("%d of %d pods are ready", status.ReadyReplicas, replicas), nil
+	case status.UpdateRevision != status.CurrentRevision:
+		return fmt.Sprintf("%d out of %d new pods have been updated", status.UpdatedReplicas, replicas), nil
+	}
+
+	return "", nil
+}
+
+func getDaemonSetRolloutStatus(daemonSet *appsv1.DaemonSet, generation int64) (string, error) {
+	if daemonSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType {
+		return "", fmt.Errorf("Daemonset '%s' uses the OnDelete update strategy, its pods have to be deleted to run the proxy", daemonSet.Name)
+	}
+
+	if daemonSet.Status.ObservedGeneration < generation {
+		return "waiting for the daemonset update to be observed", nil
+	}
+
+	status := daemonSet.Status
+
+	switch {
+	case status.UpdatedNumberScheduled < status.DesiredNumberScheduled:
+		return fmt.Sprintf("%d out of %d new pods have been updated", status.UpdatedNumberScheduled, status.DesiredNumberScheduled), nil
+	case status.NumberAvailable < status.DesiredNumberScheduled:
+		return fmt.Sprintf("%d of %d updated pods are available", status.NumberAvailable, status.DesiredNumberScheduled), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,240 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForRollout(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	deployment := newRolledOutDeployment("my-app", "my-app")
+
+	forwarder := &Forwarder{
+		view:        view,
+		namespace:   "backend",
+		clientSet:   fake.NewSimpleClientset(deployment, newProxyPod("my-app-5d8f7b9c4-bd4sk", true)),
+		stopChannel: make(chan struct{}),
+	}
+
+	// When
+	err := forwarder.waitForRollout(ctx, newDeploymentWorkload(deployment))
+
+	// Then
+	assert.Nil(t, err)
+}
+
+func TestWaitForRolloutWhenTimeout(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⏳  Rollout of %s '%s': %s...\n", "deployment", "my-app", "0 of 1 updated replicas are available")
+
+	deployment := newRolledOutDeployment("my-app", "my-app")
+	deployment.Status.AvailableReplicas = 0
+
+	forwarder := &Forwarder{
+		view:        view,
+		namespace:   "backend",
+		clientSet:   fake.NewSimpleClientset(deployment),
+		stopChannel: make(chan struct{}),
+	}
+
+	defer func(timeout time.Duration) { rolloutTimeout = timeout }(rolloutTimeout)
+	rolloutTimeout = 100 * time.Millisecond
+
+	// When
+	err := forwarder.waitForRollout(ctx, newDeploymentWorkload(deployment))
+
+	// Then
+	assert.EqualError(t, err, "Timeout after 100ms while waiting for the rollout of deployment 'my-app' (0 of 1 updated replicas are available)")
+}
+
+func TestGetRolloutStatus(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	deployment := newRolledOutDeployment("my-app", "my-app")
+	deployment.Generation = 3
+	deployment.Status.ObservedGeneration = 2
+
+	forwarder := &Forwarder{
+		namespace: "backend",
+		clientSet: fake.NewSimpleClientset(deployment),
+	}
+
+	// When
+	status, err := forwarder.getRolloutStatus(ctx, TargetDeployment, "my-app", 3)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "waiting for the deployment update to be observed", status)
+}
+
+func TestGetRolloutStatusWhenProxyPodsAreNotReady(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	deployment := newRolledOutDeployment("my-app", "my-app")
+
+	// Pods still running the application are ready but are not the proxy ones
+	appPod := newPod("my-app-7c6d5b4a3-jk2lm", corev1.PodRunning, true, time.Now())
+
+	forwarder := &Forwarder{
+		namespace: "backend",
+		clientSet: fake.NewSimpleClientset(deployment, newProxyPod("my-app-5d8f7b9c4-bd4sk", false), appPod),
+	}
+
+	// When
+	status, err := forwarder.getRolloutStatus(ctx, TargetDeployment, "my-app", deployment.Generation)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "waiting for the proxy pods to be ready", status)
+}
+
+func TestGetRolloutStatusWhenStatefulSet(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	statefulSet := newStatefulSet("my-app", "my-app")
+	statefulSet.Status.UpdateRevision = "my-app-6f7d8c9b5"
+
+	forwarder := &Forwarder{
+		namespace: "backend",
+		clientSet: fake.NewSimpleClientset(statefulSet),
+	}
+
+	// When
+	status, err := forwarder.getRolloutStatus(ctx, TargetStatefulSet, "my-app", statefulSet.Generation)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "0 out of 1 new pods have been updated", status)
+}
+
+func TestGetRolloutStatusWhenDaemonSetIsUpdatedOnDelete(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	daemonSet := newDaemonSet("my-app", "my-app")
+	daemonSet.Spec.UpdateStrategy.Type = appsv1.OnDeleteDaemonSetStrategyType
+
+	forwarder := &Forwarder{
+		namespace: "backend",
+		clientSet: fake.NewSimpleClientset(daemonSet),
+	}
+
+	// When
+	status, err := forwarder.getRolloutStatus(ctx, TargetDaemonSet, "my-app", daemonSet.Generation)
+
+	// Then
+	assert.Equal(t, "", status)
+	assert.EqualError(t, err, "Daemonset 'my-app' uses the OnDelete update strategy, its pods have to be deleted to run the proxy")
+}
+
+func newRolledOutDeployment(name string, app string) *appsv1.Deployment {
+	var replicas int32 = 1
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "backend",
+			Labels:     map[string]string{"app": app},
+			UID:        "3b0f2a1c-d0c5-4e7e-9a3e-6d1b8f0e2c4a",
+			Generation: 2,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           1,
+			UpdatedReplicas:    1,
+			AvailableReplicas:  1,
+		},
+	}
+}
+
+func newStatefulSet(name string, app string) *appsv1.StatefulSet {
+	var replicas int32 = 1
+
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "backend",
+			Labels:     map[string]string{"app": app},
+			UID:        "8d2e4f6a-1b3c-4d5e-8f9a-0b1c2d3e4f5a",
+			Generation: 2,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "acme.tld/" + app}},
+				},
+			},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			ReadyReplicas:      1,
+			CurrentRevision:    name + "-5d8f7b9c4",
+			UpdateRevision:     name + "-5d8f7b9c4",
+		},
+	}
+}
+
+func newDaemonSet(name string, app string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  "backend",
+			Labels:     map[string]string{"app": app},
+			UID:        "5c7e9a1b-3d5f-4a6b-9c8d-7e6f5a4b3c2d",
+			Generation: 2,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "acme.tld/" + app}},
+				},
+			},
+		},
+		Status: appsv1.DaemonSetStatus{
+			ObservedGeneration:     2,
+			DesiredNumberScheduled: 1,
+			UpdatedNumberScheduled: 1,
+			NumberAvailable:        1,
+		},
+	}
+}
+
+func newProxyPod(name string, ready bool) *corev1.Pod {
+	pod := newPod(name, corev1.PodRunning, ready, time.Now())
+	pod.Labels = map[string]string{"app": "my-app"}
+	pod.Spec.Containers[0].Image = ProxyDockerImage
+
+	return pod
+}
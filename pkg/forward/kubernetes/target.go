@@ -0,0 +1,323 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	TargetPod         = "pod"
+	TargetDeployment  = "deployment"
+	TargetStatefulSet = "statefulset"
+	TargetDaemonSet   = "daemonset"
+	TargetService     = "service"
+)
+
+// targetKindAliases are the resource names accepted by kubectl for each target kind
+var targetKindAliases = map[string]string{
+	"po":           TargetPod,
+	"pod":          TargetPod,
+	"pods":         TargetPod,
+	"deploy":       TargetDeployment,
+	"deployment":   TargetDeployment,
+	"deployments":  TargetDeployment,
+	"sts":          TargetStatefulSet,
+	"statefulset":  TargetStatefulSet,
+	"statefulsets": TargetStatefulSet,
+	"ds":           TargetDaemonSet,
+	"daemonset":    TargetDaemonSet,
+	"daemonsets":   TargetDaemonSet,
+	"svc":          TargetService,
+	"service":      TargetService,
+	"services":     TargetService,
+}
+
+// parseTarget splits a "<kind>/<name>" target, as given to kubectl port-forward
+func parseTarget(target string) (string, string, error) {
+	parts := strings.SplitN(target, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf("Invalid target '%s', please use one of pod/<name>, deployment/<name>, statefulset/<name>, daemonset/<name> or service/<name>", target)
+	}
+
+	kind, ok := targetKindAliases[strings.ToLower(parts[0])]
+	if !ok {
+		return "", "", fmt.Errorf("Unsupported target kind '%s', please use one of pod, deployment, statefulset, daemonset or service", parts[0])
+	}
+
+	return kind, parts[1], nil
+}
+
+// podFilter matches the pods a forward could be served by
+type podFilter struct {
+	selector labels.Selector
+	name     string
+	proxy    bool
+}
+
+func newSelectorPodFilter(selector string) (*podFilter, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid selector '%s': %w", selector, err)
+	}
+
+	return &podFilter{selector: parsed}, nil
+}
+
+func (p *podFilter) listOptions() metav1.ListOptions {
+	if p.name != "" {
+		return metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", p.name).String()}
+	}
+
+	return metav1.ListOptions{LabelSelector: p.selector.String()}
+}
+
+func (p *podFilter) matches(pod *apiv1.Pod) bool {
+	if p.proxy && !hasProxyContainer(pod) {
+		return false
+	}
+
+	if p.name != "" {
+		return pod.Name == p.name
+	}
+
+	return p.selector.Matches(labels.Set(pod.Labels))
+}
+
+// getPodFilter returns the filter matching the pods of the target or of the selector of labels.
+// When the target is a service, the service is also returned so that its ports can be translated
+// to the container ones.
+func (f *Forwarder) getPodFilter(ctx context.Context) (*podFilter, *apiv1.Service, error) {
+	if f.target == "" {
+		filter, err := newSelectorPodFilter(f.getSelector())
+		return filter, nil, err
+	}
+
+	kind, name, err := parseTarget(f.target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch kind {
+	case TargetPod:
+		return &podFilter{name: name}, nil, nil
+
+	case TargetDeployment, TargetStatefulSet, TargetDaemonSet:
+		w, err := getWorkload(ctx, f.clientSet, f.namespace, kind, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unable to find %s '%s': %w", kind, name, err)
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(w.selector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid selector on %s '%s': %w", kind, name, err)
+		}
+
+		return &podFilter{selector: selector}, nil, nil
+
+	case TargetService:
+		service, err := f.clientSet.CoreV1().Services(f.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unable to find service '%s': %w", name, err)
+		}
+
+		if len(service.Spec.Selector) == 0 {
+			return nil, nil, fmt.Errorf("Service '%s' does not have any selector, unable to find its pods", name)
+		}
+
+		return &podFilter{selector: labels.SelectorFromSet(service.Spec.Selector)}, service, nil
+	}
+
+	return nil, nil, fmt.Errorf("Unsupported target '%s'", f.target)
+}
+
+// getPodForFilter returns the best running pod matching the given filter, the same way kubectl does
+func (f *Forwarder) getPodForFilter(ctx context.Context, filter *podFilter) (*apiv1.Pod, error) {
+	if filter.name != "" {
+		pod, err := f.clientSet.CoreV1().Pods(f.namespace).Get(ctx, filter.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to find pod '%s': %w", filter.name, err)
+		}
+
+		if !isPodRunning(pod) || isPodTerminating(pod) {
+			return nil, fmt.Errorf("Pod '%s' is not running (current phase is '%s')", filter.name, pod.Status.Phase)
+		}
+
+		return pod, nil
+	}
+
+	selector := filter.selector.String()
+
+	pods, err := f.clientSet.CoreV1().Pods(f.namespace).List(ctx, filter.listOptions())
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find pods for selector '%s': %w", selector, err)
+	}
+
+	if len(pods.Items) < 1 {
+		return nil, fmt.Errorf("No pod available for selector '%s'", selector)
+	}
+
+	if filter.proxy {
+		selector = selector + " (running the proxy)"
+	}
+
+	runningPods := make([]apiv1.Pod, 0, len(pods.Items))
+
+	for _, pod := range pods.Items {
+		if filter.matches(&pod) && isPodRunning(&pod) && !isPodTerminating(&pod) {
+			runningPods = append(runningPods, pod)
+		}
+	}
+
+	if len(runningPods) < 1 {
+		return nil, fmt.Errorf("No runnning pod available for selector '%s'", selector)
+	}
+
+	sort.SliceStable(runningPods, func(i, j int) bool {
+		return isPodPreferred(&runningPods[i], &runningPods[j])
+	})
+
+	return &runningPods[0], nil
+}
+
+// isPodPreferred returns true if the first pod should be preferred over the second one:
+// ready pods first, then the ones that restarted the less, then the oldest ones
+func isPodPreferred(first *apiv1.Pod, second *apiv1.Pod) bool {
+	if firstReady, secondReady := isPodReady(first), isPodReady(second); firstReady != secondReady {
+		return firstReady
+	}
+
+	if firstRestarts, secondRestarts := getPodRestarts(first), getPodRestarts(second); firstRestarts != secondRestarts {
+		return firstRestarts < secondRestarts
+	}
+
+	return first.CreationTimestamp.Before(&second.CreationTimestamp)
+}
+
+// isPodTerminating returns true once the pod has been asked to be deleted, while it could still be running
+func isPodTerminating(pod *apiv1.Pod) bool {
+	return pod.DeletionTimestamp != nil
+}
+
+// isProxyImage returns true if the given image is the proxy one, whatever its version, so that proxies
+// deployed by other versions of Monday are recognized as well
+func isProxyImage(image string) bool {
+	return image == ProxyDockerImageName || strings.HasPrefix(image, ProxyDockerImageName+":")
+}
+
+// hasProxyContainer returns true if the proxy image runs in one of the pod containers
+func hasProxyContainer(pod *apiv1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if isProxyImage(container.Image) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPodHealthy returns true if the pod is able to serve a forward
+func isPodHealthy(pod *apiv1.Pod) bool {
+	return isPodRunning(pod) && isPodReady(pod) && !isPodTerminating(pod)
+}
+
+func isPodReady(pod *apiv1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func getPodRestarts(pod *apiv1.Pod) int32 {
+	var restarts int32
+
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+
+	return restarts
+}
+
+// translatePorts resolves the remote part of "<local>:<remote>" ports to the pod container ports.
+// When a service is given, remote ports are the service ones and are translated to their target
+// ports. Otherwise, remote ports could be given as container port names.
+func translatePorts(ports []string, pod *apiv1.Pod, service *apiv1.Service) ([]string, error) {
+	translated := make([]string, 0, len(ports))
+
+	for _, port := range ports {
+		localPort, remotePort := port, port
+		if parts := strings.SplitN(port, ":", 2); len(parts) == 2 {
+			localPort, remotePort = parts[0], parts[1]
+		}
+
+		var containerPort int32
+		var err error
+
+		if service != nil {
+			containerPort, err = getServiceTargetPort(service, pod, remotePort)
+		} else {
+			containerPort, err = getContainerPort(pod, remotePort)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		translated = append(translated, fmt.Sprintf("%s:%d", localPort, containerPort))
+	}
+
+	return translated, nil
+}
+
+// getServiceTargetPort returns the container port targeted by the given service port (number or name)
+func getServiceTargetPort(service *apiv1.Service, pod *apiv1.Pod, port string) (int32, error) {
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Name != port && strconv.Itoa(int(servicePort.Port)) != port {
+			continue
+		}
+
+		if servicePort.Protocol != "" && servicePort.Protocol != apiv1.ProtocolTCP {
+			return 0, fmt.Errorf("Port '%s' of service '%s' is not a TCP port", port, service.Name)
+		}
+
+		switch {
+		case servicePort.TargetPort.Type == intstr.String:
+			return getContainerPort(pod, servicePort.TargetPort.StrVal)
+		case servicePort.TargetPort.IntVal == 0:
+			// Target port defaults to the service port
+			return servicePort.Port, nil
+		default:
+			return servicePort.TargetPort.IntVal, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Service '%s' does not have any port '%s'", service.Name, port)
+}
+
+// getContainerPort returns the given port number or, if a name is given, the matching container port of the pod
+func getContainerPort(pod *apiv1.Pod, port string) (int32, error) {
+	if number, err := strconv.ParseInt(port, 10, 32); err == nil {
+		return int32(number), nil
+	}
+
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == port {
+				return containerPort.ContainerPort, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("Pod '%s' does not have any container port named '%s'", pod.Name, port)
+}
@@ -0,0 +1,237 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseTarget(t *testing.T) {
+	// When
+	kind, name, err := parseTarget("svc/my-service")
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, TargetService, kind)
+	assert.Equal(t, "my-service", name)
+}
+
+func TestParseTargetWhenInvalid(t *testing.T) {
+	// When
+	_, _, errNoName := parseTarget("my-service")
+	_, _, errUnknownKind := parseTarget("job/my-job")
+
+	// Then
+	assert.EqualError(t, errNoName, "Invalid target 'my-service', please use one of pod/<name>, deployment/<name>, statefulset/<name>, daemonset/<name> or service/<name>")
+	assert.EqualError(t, errUnknownKind, "Unsupported target kind 'job', please use one of pod, deployment, statefulset, daemonset or service")
+}
+
+func TestGetPodForFilterWithServiceTarget(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-service", Namespace: "backend"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "my-app"},
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromString("web")},
+				{Name: "grpc", Port: 9000, TargetPort: intstr.FromInt(9090)},
+				{Name: "metrics", Port: 8001},
+			},
+		},
+	}
+
+	forwarder := newTargetForwarder("service/my-service",
+		service,
+		newPod("my-app-pending", corev1.PodPending, false, time.Now()),
+		newPod("my-app-not-ready", corev1.PodRunning, false, time.Now().Add(-time.Hour)),
+		newPod("my-app-ready", corev1.PodRunning, true, time.Now()),
+	)
+
+	// When
+	filter, resolvedService, err := forwarder.getPodFilter(ctx)
+	assert.Nil(t, err)
+
+	pod, err := forwarder.getPodForFilter(ctx, filter)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "my-app-ready", pod.Name)
+	assert.Equal(t, "my-service", resolvedService.Name)
+
+	ports, err := translatePorts([]string{"8080:80", "9000:grpc", "8001"}, pod, resolvedService)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"8080:8000", "9000:9090", "8001:8001"}, ports)
+}
+
+func TestGetPodWithServiceTargetWhenUnknownPort(t *testing.T) {
+	// Given
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-service", Namespace: "backend"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "my-app"},
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+
+	pod := newPod("my-app", corev1.PodRunning, true, time.Now())
+
+	// When
+	ports, err := translatePorts([]string{"8080:8080"}, pod, service)
+
+	// Then
+	assert.Nil(t, ports)
+	assert.EqualError(t, err, "Service 'my-service' does not have any port '8080'")
+}
+
+func TestGetPodForFilterWithDeploymentTarget(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-deployment", Namespace: "backend"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}},
+		},
+	}
+
+	forwarder := newTargetForwarder("deploy/my-deployment",
+		deployment,
+		newPod("my-app-recent", corev1.PodRunning, true, time.Now()),
+		newPod("my-app-old", corev1.PodRunning, true, time.Now().Add(-time.Hour)),
+	)
+
+	// When
+	filter, service, err := forwarder.getPodFilter(ctx)
+	assert.Nil(t, err)
+
+	pod, err := forwarder.getPodForFilter(ctx, filter)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Nil(t, service)
+	assert.Equal(t, "my-app-old", pod.Name)
+
+	ports, err := translatePorts([]string{"8080:web", "8001:8001"}, pod, service)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"8080:8000", "8001:8001"}, ports)
+}
+
+func TestGetPodForFilterWithStatefulSetTarget(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-statefulset", Namespace: "backend"},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}},
+		},
+	}
+
+	forwarder := newTargetForwarder("statefulset/my-statefulset",
+		statefulSet,
+		newPod("my-app-0", corev1.PodRunning, true, time.Now()),
+	)
+
+	// When
+	filter, _, err := forwarder.getPodFilter(ctx)
+	assert.Nil(t, err)
+
+	pod, err := forwarder.getPodForFilter(ctx, filter)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "my-app-0", pod.Name)
+}
+
+func TestGetPodForFilterWithPodTargetWhenNotRunning(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	forwarder := newTargetForwarder("pod/my-app",
+		newPod("my-app", corev1.PodPending, false, time.Now()),
+	)
+
+	// When
+	filter, _, err := forwarder.getPodFilter(ctx)
+	assert.Nil(t, err)
+
+	pod, err := forwarder.getPodForFilter(ctx, filter)
+
+	// Then
+	assert.Nil(t, pod)
+	assert.EqualError(t, err, "Pod 'my-app' is not running (current phase is 'Pending')")
+}
+
+func TestGetWorkloadsWhenTargetIsNotAWorkload(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	forwarder := newTargetForwarder("service/my-service")
+
+	// When
+	workloads, err := forwarder.getWorkloads(ctx)
+
+	// Then
+	assert.Nil(t, workloads)
+	assert.EqualError(t, err, "Target 'service/my-service' is not supported by remote-forwards, please use a deployment/<name>, statefulset/<name> or daemonset/<name> target")
+}
+
+func newTargetForwarder(target string, objects ...runtime.Object) *Forwarder {
+	return &Forwarder{
+		namespace: "backend",
+		target:    target,
+		clientSet: fake.NewSimpleClientset(objects...),
+	}
+}
+
+func newPod(name string, phase corev1.PodPhase, ready bool, createdAt time.Time) *corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "backend",
+			Labels:            map[string]string{"app": "my-app"},
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Ports: []corev1.ContainerPort{
+						{Name: "web", ContainerPort: 8000},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: readyStatus},
+			},
+		},
+	}
+}
+
+func TestIsProxyImage(t *testing.T) {
+	assert.True(t, isProxyImage(ProxyDockerImage))
+	assert.True(t, isProxyImage("ekofr/monday-proxy"))
+	assert.True(t, isProxyImage("ekofr/monday-proxy:1.0.0"))
+	assert.False(t, isProxyImage("ekofr/monday-proxy-other:1.0.0"))
+	assert.False(t, isProxyImage("nginx:latest"))
+}
@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	// TransportAuto port-forwards over WebSocket and falls back to SPDY in case the API server
+	// (or a gateway in front of it) does not support it
+	TransportAuto = "auto"
+
+	// TransportWebSocket port-forwards over WebSocket only
+	TransportWebSocket = "websocket"
+
+	// TransportSPDY port-forwards over SPDY only, like older Kubernetes clients do
+	TransportSPDY = "spdy"
+)
+
+func isTransportSupported(transport string) bool {
+	return transport == "" || transport == TransportAuto || transport == TransportWebSocket || transport == TransportSPDY
+}
+
+// newDialer returns the dialer opening port-forward connections to the given pod over the forward transport
+func (f *Forwarder) newDialer(podName string) (httpstream.Dialer, error) {
+	url := f.restClient.Post().
+		Resource("pods").
+		Namespace(f.namespace).
+		Name(podName).
+		SubResource("portforward").
+		Param("timeout", "30s").
+		URL()
+
+	transport, upgrader, err := spdy.RoundTripperFor(f.clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	spdyDialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+
+	if f.transport == TransportSPDY {
+		return spdyDialer, nil
+	}
+
+	websocketDialer, err := portforward.NewSPDYOverWebsocketDialer(url, f.clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.transport == TransportWebSocket {
+		return websocketDialer, nil
+	}
+
+	return portforward.NewFallbackDialer(websocketDialer, spdyDialer, shouldFallbackToSPDY), nil
+}
+
+// shouldFallbackToSPDY indicates if the WebSocket connection failed because upgrading it is not supported
+func shouldFallbackToSPDY(err error) bool {
+	return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+}
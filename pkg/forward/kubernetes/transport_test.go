@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestNewDialer(t *testing.T) {
+	// Given
+	testCases := []struct {
+		transport string
+		expected  []string
+	}{
+		{transport: "", expected: []string{"GET", "POST"}},
+		{transport: TransportAuto, expected: []string{"GET", "POST"}},
+		{transport: TransportWebSocket, expected: []string{"GET"}},
+		{transport: TransportSPDY, expected: []string{"POST"}},
+	}
+
+	for _, testCase := range testCases {
+		forwarder, requests := newTransportForwarder(t, testCase.transport)
+
+		// When
+		dialer, err := forwarder.newDialer("my-app-5d8f7b9c4-bd4sk")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err = dialer.Dial("portforward.k8s.io")
+
+		// Then
+		assert.NotNil(t, err)
+
+		methods := make([]string, 0)
+		for len(requests) > 0 {
+			methods = append(methods, <-requests)
+		}
+
+		assert.Equal(t, testCase.expected, methods, "transport '%s'", testCase.transport)
+	}
+}
+
+func TestForwardWhenTransportIsNotSupported(t *testing.T) {
+	// Given
+	forwarder := &Forwarder{
+		name:      "test-forward",
+		namespace: "backend",
+		labels:    map[string]string{"app": "my-app"},
+		transport: "http3",
+	}
+
+	// When
+	err := forwarder.Forward(context.Background())
+
+	// Then
+	assert.EqualError(t, err, "Unsupported transport 'http3', please use 'auto', 'websocket' or 'spdy'")
+}
+
+// newTransportForwarder returns a forwarder whose API server answers port-forward requests without upgrading
+// them. Methods of the received requests are sent to the returned channel.
+func newTransportForwarder(t *testing.T, transport string) (*Forwarder, chan string) {
+	requests := make(chan string, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests <- req.Method
+
+		res.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, _ := url.Parse(server.URL)
+
+	restClient, err := restclient.NewRESTClient(serverURL, "/api/v1", restclient.ClientContentConfig{}, flowcontrol.NewFakeAlwaysRateLimiter(), &http.Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Forwarder{
+		namespace:    "backend",
+		transport:    transport,
+		clientConfig: &restclient.Config{Host: server.URL},
+		restClient:   restClient,
+	}, requests
+}
@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// watchRetryDelay is the delay before watching pods again when the watch could not be opened
+var watchRetryDelay = 2 * time.Second
+
+// watchServingPod watches the pods matching the given filter and closes the switch channel as soon as
+// the serving pod cannot serve the forward anymore (terminated, deleted or not ready) while another
+// healthy pod is available. It returns when the context is done or once the switch channel is closed.
+func (f *Forwarder) watchServingPod(ctx context.Context, filter *podFilter, servingPod string, switchChannel chan<- struct{}) {
+	waiting := false
+
+	for {
+		pods := make(map[string]*apiv1.Pod)
+
+		list, err := f.clientSet.CoreV1().Pods(f.namespace).List(ctx, filter.listOptions())
+		if err == nil {
+			for i := range list.Items {
+				if filter.matches(&list.Items[i]) {
+					pods[list.Items[i].Name] = &list.Items[i]
+				}
+			}
+
+			if f.shouldSwitchPod(pods, servingPod, &waiting) {
+				close(switchChannel)
+				return
+			}
+		}
+
+		var watcher watch.Interface
+
+		if err == nil {
+			options := filter.listOptions()
+			options.ResourceVersion = list.ResourceVersion
+
+			watcher, err = f.clientSet.CoreV1().Pods(f.namespace).Watch(ctx, options)
+		}
+
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryDelay):
+				continue
+			}
+		}
+
+		if f.watchPodEvents(ctx, watcher, filter, pods, servingPod, &waiting) {
+			close(switchChannel)
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// watchPodEvents applies the watch events on the given pods until a switch is needed (returns true)
+// or the watch is closed (returns false)
+func (f *Forwarder) watchPodEvents(ctx context.Context, watcher watch.Interface, filter *podFilter, pods map[string]*apiv1.Pod, servingPod string, waiting *bool) bool {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false
+			}
+
+			pod, ok := event.Object.(*apiv1.Pod)
+			if !ok || !filter.matches(pod) {
+				continue
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				pods[pod.Name] = pod
+			case watch.Deleted:
+				delete(pods, pod.Name)
+			default:
+				continue
+			}
+
+			if f.shouldSwitchPod(pods, servingPod, waiting) {
+				return true
+			}
+		}
+	}
+}
+
+// shouldSwitchPod returns true when the serving pod is not healthy anymore and another one is.
+// When no healthy pod is available, it lets the user know the forward is waiting for one.
+func (f *Forwarder) shouldSwitchPod(pods map[string]*apiv1.Pod, servingPod string, waiting *bool) bool {
+	reason := ""
+
+	pod, ok := pods[servingPod]
+
+	switch {
+	case !ok:
+		reason = "has been deleted"
+	case isPodTerminating(pod):
+		reason = "is terminating"
+	case !isPodRunning(pod):
+		reason = "is not running anymore"
+	case !isPodReady(pod):
+		reason = "is not ready anymore"
+	default:
+		*waiting = false
+		return false
+	}
+
+	for name, candidate := range pods {
+		if name != servingPod && isPodHealthy(candidate) {
+			f.view.Writef("🔀  Pod '%s' serving forward '%s' %s, switching to another pod\n", servingPod, f.name, reason)
+			return true
+		}
+	}
+
+	if !*waiting {
+		f.view.Writef("⏳  Pod '%s' serving forward '%s' %s, waiting for another pod to be ready...\n", servingPod, f.name, reason)
+		*waiting = true
+	}
+
+	return false
+}
@@ -0,0 +1,134 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/ui"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWatchServingPodWhenTerminating(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🔀  Pod '%s' serving forward '%s' %s, switching to another pod\n", "my-app-a", "test-forward", "is terminating")
+
+	servingPod := newPod("my-app-a", corev1.PodRunning, true, time.Now())
+
+	forwarder, watcher := newWatchForwarder(view, servingPod, newPod("my-app-b", corev1.PodRunning, true, time.Now()))
+
+	switchChannel := make(chan struct{})
+	go forwarder.watchServingPod(ctx, newTestPodFilter(), "my-app-a", switchChannel)
+
+	// When
+	terminatingPod := servingPod.DeepCopy()
+	deletionTime := metav1.Now()
+	terminatingPod.DeletionTimestamp = &deletionTime
+
+	watcher.Modify(terminatingPod)
+
+	// Then
+	assertSwitched(t, switchChannel)
+}
+
+func TestWatchServingPodWaitsForAHealthyPod(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	gomock.InOrder(
+		view.EXPECT().Writef("⏳  Pod '%s' serving forward '%s' %s, waiting for another pod to be ready...\n", "my-app-a", "test-forward", "has been deleted"),
+		view.EXPECT().Writef("🔀  Pod '%s' serving forward '%s' %s, switching to another pod\n", "my-app-a", "test-forward", "has been deleted"),
+	)
+
+	servingPod := newPod("my-app-a", corev1.PodRunning, true, time.Now())
+
+	forwarder, watcher := newWatchForwarder(view, servingPod)
+
+	switchChannel := make(chan struct{})
+	go forwarder.watchServingPod(ctx, newTestPodFilter(), "my-app-a", switchChannel)
+
+	// When
+	watcher.Delete(servingPod)
+	watcher.Add(newPod("my-app-b", corev1.PodPending, false, time.Now()))
+
+	select {
+	case <-switchChannel:
+		t.Fatal("Forward should not switch to a pod that is not ready")
+	default:
+	}
+
+	watcher.Modify(newPod("my-app-b", corev1.PodRunning, true, time.Now()))
+
+	// Then
+	assertSwitched(t, switchChannel)
+}
+
+func TestWatchServingPodWhenAlreadyGone(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🔀  Pod '%s' serving forward '%s' %s, switching to another pod\n", "my-app-a", "test-forward", "is not ready anymore")
+
+	forwarder, _ := newWatchForwarder(view,
+		newPod("my-app-a", corev1.PodRunning, false, time.Now()),
+		newPod("my-app-b", corev1.PodRunning, true, time.Now()),
+	)
+
+	switchChannel := make(chan struct{})
+
+	// When
+	forwarder.watchServingPod(ctx, newTestPodFilter(), "my-app-a", switchChannel)
+
+	// Then
+	assertSwitched(t, switchChannel)
+}
+
+func newWatchForwarder(view ui.View, objects ...runtime.Object) (*Forwarder, *watch.FakeWatcher) {
+	watcher := watch.NewFake()
+
+	clientSet := fake.NewSimpleClientset(objects...)
+	clientSet.PrependWatchReactor("pods", k8stesting.DefaultWatchReactor(watcher, nil))
+
+	return &Forwarder{
+		view:      view,
+		name:      "test-forward",
+		namespace: "backend",
+		clientSet: clientSet,
+	}, watcher
+}
+
+func newTestPodFilter() *podFilter {
+	return &podFilter{selector: labels.SelectorFromSet(map[string]string{"app": "my-app"})}
+}
+
+func assertSwitched(t *testing.T, switchChannel chan struct{}) {
+	select {
+	case <-switchChannel:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Forward should have switched to another pod")
+	}
+}
@@ -0,0 +1,170 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// workload is a Kubernetes resource running pods from a template, in which the proxy could be deployed:
+// a deployment, a statefulset or a daemonset
+type workload struct {
+	metav1.Object
+	kind        string
+	template    *apiv1.PodTemplateSpec
+	selector    *metav1.LabelSelector
+	deployment  *appsv1.Deployment
+	statefulSet *appsv1.StatefulSet
+	daemonSet   *appsv1.DaemonSet
+}
+
+func newDeploymentWorkload(deployment *appsv1.Deployment) *workload {
+	return &workload{
+		Object:     deployment,
+		kind:       TargetDeployment,
+		template:   &deployment.Spec.Template,
+		selector:   deployment.Spec.Selector,
+		deployment: deployment,
+	}
+}
+
+func newStatefulSetWorkload(statefulSet *appsv1.StatefulSet) *workload {
+	return &workload{
+		Object:      statefulSet,
+		kind:        TargetStatefulSet,
+		template:    &statefulSet.Spec.Template,
+		selector:    statefulSet.Spec.Selector,
+		statefulSet: statefulSet,
+	}
+}
+
+func newDaemonSetWorkload(daemonSet *appsv1.DaemonSet) *workload {
+	return &workload{
+		Object:    daemonSet,
+		kind:      TargetDaemonSet,
+		template:  &daemonSet.Spec.Template,
+		selector:  daemonSet.Spec.Selector,
+		daemonSet: daemonSet,
+	}
+}
+
+// String returns the workload as a "<kind>/<name>" target
+func (w *workload) String() string {
+	return fmt.Sprintf("%s/%s", w.kind, w.GetName())
+}
+
+// getContainer returns the index of the container having the given name, or the first one if no name is given
+func (w *workload) getContainer(name string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	for index, container := range w.template.Spec.Containers {
+		if container.Name == name {
+			return index, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Container '%s' does not exist in %s '%s'", name, w.kind, w.GetName())
+}
+
+// getWorkload retrieves the workload of the given kind and name. An empty kind stands for a deployment.
+func getWorkload(ctx context.Context, clientSet kubernetes.Interface, namespace, kind, name string) (*workload, error) {
+	switch kind {
+	case "", TargetDeployment:
+		deployment, err := clientSet.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return newDeploymentWorkload(deployment), nil
+
+	case TargetStatefulSet:
+		statefulSet, err := clientSet.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return newStatefulSetWorkload(statefulSet), nil
+
+	case TargetDaemonSet:
+		daemonSet, err := clientSet.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return newDaemonSetWorkload(daemonSet), nil
+	}
+
+	return nil, fmt.Errorf("Unsupported workload kind '%s'", kind)
+}
+
+// listWorkloads returns the deployments, statefulsets and daemonsets matching the given selector of labels
+func listWorkloads(ctx context.Context, clientSet kubernetes.Interface, namespace, selector string) ([]*workload, error) {
+	options := metav1.ListOptions{LabelSelector: selector}
+	workloads := make([]*workload, 0)
+
+	deployments, err := clientSet.AppsV1().Deployments(namespace).List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range deployments.Items {
+		workloads = append(workloads, newDeploymentWorkload(&deployments.Items[i]))
+	}
+
+	statefulSets, err := clientSet.AppsV1().StatefulSets(namespace).List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range statefulSets.Items {
+		workloads = append(workloads, newStatefulSetWorkload(&statefulSets.Items[i]))
+	}
+
+	daemonSets, err := clientSet.AppsV1().DaemonSets(namespace).List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range daemonSets.Items {
+		workloads = append(workloads, newDaemonSetWorkload(&daemonSets.Items[i]))
+	}
+
+	return workloads, nil
+}
+
+// updateWorkload updates the given workload, which is then replaced by its updated version
+func updateWorkload(ctx context.Context, clientSet kubernetes.Interface, namespace string, w *workload) error {
+	switch w.kind {
+	case TargetStatefulSet:
+		statefulSet, err := clientSet.AppsV1().StatefulSets(namespace).Update(ctx, w.statefulSet, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		if statefulSet != nil {
+			*w = *newStatefulSetWorkload(statefulSet)
+		}
+
+	case TargetDaemonSet:
+		daemonSet, err := clientSet.AppsV1().DaemonSets(namespace).Update(ctx, w.daemonSet, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		if daemonSet != nil {
+			*w = *newDaemonSetWorkload(daemonSet)
+		}
+
+	default:
+		deployment, err := clientSet.AppsV1().Deployments(namespace).Update(ctx, w.deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		if deployment != nil {
+			*w = *newDeploymentWorkload(deployment)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInterceptWorkloadWithContainer(t *testing.T) {
+	// Given
+	useTempAuditLog(t)
+
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("📡  Setting up proxy on application '%s', please wait for the rollout to be complete...\n", "my-remote-app")
+
+	// Service mesh injects its Envoy sidecar as the first container
+	deployment := newInterceptionDeployment()
+	deployment.Spec.Template.Spec.Containers = append([]corev1.Container{
+		{Name: "envoy", Image: "envoyproxy/envoy"},
+	}, deployment.Spec.Template.Spec.Containers...)
+
+	clientSet := fake.NewSimpleClientset(deployment)
+
+	forwarder := newWorkloadForwarder(view, clientSet, RemoteOptions{Container: "app"})
+
+	// When
+	err := forwarder.interceptWorkload(ctx, newDeploymentWorkload(deployment.DeepCopy()))
+
+	// Then
+	assert.Nil(t, err)
+
+	intercepted, err := clientSet.AppsV1().Deployments("backend").Get(ctx, "my-remote-app", metav1.GetOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "envoyproxy/envoy", intercepted.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, ProxyDockerImage, intercepted.Spec.Template.Spec.Containers[1].Image)
+
+	backup := forwarder.workloads[deployment.UID]
+
+	assert.Equal(t, "app", backup.Container)
+	assert.Equal(t, "acme.tld/my-remote-app", backup.Image)
+}
+
+func TestInterceptWorkloadWhenContainerDoesNotExist(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	deployment := newInterceptionDeployment()
+
+	forwarder := newWorkloadForwarder(view, fake.NewSimpleClientset(deployment), RemoteOptions{Container: "worker"})
+
+	// When
+	err := forwarder.interceptWorkload(ctx, newDeploymentWorkload(deployment))
+
+	// Then
+	assert.EqualError(t, err, "Container 'worker' does not exist in deployment 'my-remote-app'")
+	assert.Len(t, forwarder.workloads, 0)
+}
+
+func TestGetWorkloadsWhenAllMatching(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	clientSet := fake.NewSimpleClientset(
+		newRolledOutDeployment("my-app-api", "my-app"),
+		newStatefulSet("my-app-db", "my-app"),
+		newDaemonSet("my-app-agent", "my-app"),
+		newStatefulSet("other-app", "other-app"),
+	)
+
+	forwarder := newWorkloadForwarder(view, clientSet, RemoteOptions{AllMatching: true})
+
+	// When
+	workloads, err := forwarder.getWorkloads(ctx)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Len(t, workloads, 3)
+
+	assert.Equal(t, "deployment/my-app-api", workloads[0].String())
+	assert.Equal(t, "statefulset/my-app-db", workloads[1].String())
+	assert.Equal(t, "daemonset/my-app-agent", workloads[2].String())
+}
+
+func TestGetWorkloadsWhenSeveralMatching(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientSet := fake.NewSimpleClientset(
+		newRolledOutDeployment("my-app-api", "my-app"),
+		newStatefulSet("my-app-db", "my-app"),
+	)
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⚠️  %d workloads match selector '%s', only %s will run the proxy (use all_matching to deploy it in all of them)\n", 2, "app=my-app", gomock.Any())
+
+	forwarder := newWorkloadForwarder(view, clientSet, RemoteOptions{})
+
+	// When
+	workloads, err := forwarder.getWorkloads(ctx)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Len(t, workloads, 1)
+	assert.Equal(t, "deployment/my-app-api", workloads[0].String())
+}
+
+func TestGetWorkloadsWhenNoneMatching(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder := newWorkloadForwarder(view, fake.NewSimpleClientset(), RemoteOptions{})
+
+	// When
+	workloads, err := forwarder.getWorkloads(ctx)
+
+	// Then
+	assert.Nil(t, workloads)
+	assert.EqualError(t, err, "No deployment, statefulset or daemonset available for selector 'app=my-app'")
+}
+
+func newWorkloadForwarder(view ui.View, clientSet *fake.Clientset, options RemoteOptions) *Forwarder {
+	forwarder := &Forwarder{
+		view:      view,
+		name:      "test-remote-forward",
+		namespace: "backend",
+		labels:    map[string]string{"app": "my-app"},
+		clientSet: clientSet,
+	}
+
+	forwarder.reset()
+	forwarder.SetRemoteOptions(options)
+
+	return forwarder
+}
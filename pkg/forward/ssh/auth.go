@@ -0,0 +1,295 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/eko/monday/pkg/config"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHPort = "22"
+)
+
+var (
+	sshDirectory = filepath.Join(os.Getenv("HOME"), ".ssh")
+
+	// defaultIdentityFiles are the private keys used, if they exist, when no identity file is specified
+	defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+)
+
+// endpoint is an SSH server address
+type endpoint struct {
+	user string
+	host string
+	port string
+}
+
+func (e endpoint) address() string {
+	return net.JoinHostPort(e.host, e.port)
+}
+
+// options are the SSH connection options parsed from the forward values
+type options struct {
+	endpoint
+	jumpHosts     []endpoint
+	identityFiles []string
+	knownHosts    string
+	ignoredArgs   []string
+}
+
+// parseEndpoint parses a "[user@]host[:port]" address
+func parseEndpoint(address string) endpoint {
+	e := endpoint{host: address, port: defaultSSHPort}
+
+	if index := strings.LastIndex(e.host, "@"); index >= 0 {
+		e.user = e.host[:index]
+		e.host = e.host[index+1:]
+	}
+
+	if host, port, err := net.SplitHostPort(e.host); err == nil {
+		e.host = host
+		e.port = port
+	}
+
+	return e
+}
+
+// parseOptions parses the forward remote, the supported arguments of the ssh command (-p, -i and -l)
+// that were previously given to the ssh binary and the dedicated SSH values, which take precedence.
+// Other arguments are ignored.
+func parseOptions(values config.ForwardValues) (*options, error) {
+	opts := &options{
+		endpoint:      parseEndpoint(values.Remote),
+		jumpHosts:     make([]endpoint, 0, len(values.JumpHosts)),
+		identityFiles: make([]string, 0),
+		knownHosts:    filepath.Join(sshDirectory, "known_hosts"),
+		ignoredArgs:   make([]string, 0),
+	}
+
+	// Arguments could be given both as "-p 22" or as "-p", "22"
+	fields := strings.Fields(strings.Join(values.Args, " "))
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		var name, value string
+
+		switch {
+		case field == "-p" || field == "-i" || field == "-l":
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("Missing value for SSH argument '%s'", field)
+			}
+			i++
+			name, value = field, fields[i]
+
+		// Value could also be attached to the argument, like "-i/path/to/key"
+		case strings.HasPrefix(field, "-p") || strings.HasPrefix(field, "-i") || strings.HasPrefix(field, "-l"):
+			name, value = field[:2], field[2:]
+
+		default:
+			opts.ignoredArgs = append(opts.ignoredArgs, field)
+			continue
+		}
+
+		switch name {
+		case "-p":
+			opts.port = value
+		case "-i":
+			opts.identityFiles = append(opts.identityFiles, expandPath(value))
+		case "-l":
+			opts.user = value
+		}
+	}
+
+	if values.User != "" {
+		opts.user = values.User
+	}
+
+	if values.Port != "" {
+		opts.port = values.Port
+	}
+
+	if values.IdentityFile != "" {
+		opts.identityFiles = []string{expandPath(values.IdentityFile)}
+	}
+
+	if values.KnownHosts != "" {
+		opts.knownHosts = expandPath(values.KnownHosts)
+	}
+
+	for _, jumpHost := range values.JumpHosts {
+		opts.jumpHosts = append(opts.jumpHosts, parseEndpoint(jumpHost))
+	}
+
+	// Like ssh, use the current user when none is specified
+	var currentUser string
+	if opts.user == "" || len(opts.jumpHosts) > 0 {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve current user for SSH connection: %v", err)
+		}
+		currentUser = current.Username
+	}
+
+	if opts.user == "" {
+		opts.user = currentUser
+	}
+
+	for i := range opts.jumpHosts {
+		if opts.jumpHosts[i].user == "" {
+			opts.jumpHosts[i].user = currentUser
+		}
+	}
+
+	return opts, nil
+}
+
+// ProxyKey is the keypair generated for a session to authenticate on the Monday proxy deployed in
+// a Kubernetes cluster: its public key is the only one authorized by the proxy
+type ProxyKey struct {
+	signer gossh.Signer
+}
+
+// NewProxyKey generates a new ed25519 keypair, which only lives in memory
+func NewProxyKey() (*ProxyKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to generate SSH proxy key: %v", err)
+	}
+
+	signer, err := gossh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to generate SSH proxy key: %v", err)
+	}
+
+	return &ProxyKey{signer: signer}, nil
+}
+
+// AuthorizedKey returns the public key in the authorized_keys file format
+func (k *ProxyKey) AuthorizedKey() string {
+	return strings.TrimSpace(string(gossh.MarshalAuthorizedKey(k.signer.PublicKey())))
+}
+
+// newClientConfig returns the SSH client configuration. The returned function has to be called
+// to close the connection to the SSH agent once the client is closed.
+func (f *Forwarder) newClientConfig(opts *options) (*gossh.ClientConfig, func(), error) {
+	// Proxy host key is generated each time it is deployed and it only authorizes the session key
+	if f.proxyKey != nil {
+		return &gossh.ClientConfig{
+			User:            opts.user,
+			Auth:            []gossh.AuthMethod{gossh.PublicKeys(f.proxyKey.signer)},
+			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+			Timeout:         dialTimeout,
+		}, func() {}, nil
+	}
+
+	var closeAuth = func() {}
+
+	authMethods := make([]gossh.AuthMethod, 0)
+
+	// SSH agent
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			authMethods = append(authMethods, gossh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAuth = func() { conn.Close() }
+		}
+	}
+
+	// Identity files
+	signers, err := getIdentitySigners(opts.identityFiles)
+	if err != nil {
+		closeAuth()
+		return nil, nil, err
+	}
+
+	if len(signers) > 0 {
+		authMethods = append(authMethods, gossh.PublicKeys(signers...))
+	}
+
+	hostKeyCallback, err := getKnownHostsCallback(opts.knownHosts)
+	if err != nil {
+		closeAuth()
+		return nil, nil, err
+	}
+
+	return &gossh.ClientConfig{
+		User:            opts.user,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         dialTimeout,
+	}, closeAuth, nil
+}
+
+// getIdentitySigners loads the given private keys or the default ones in case none is given
+func getIdentitySigners(identityFiles []string) ([]gossh.Signer, error) {
+	signers := make([]gossh.Signer, 0)
+
+	if len(identityFiles) == 0 {
+		for _, name := range defaultIdentityFiles {
+			path := filepath.Join(sshDirectory, name)
+
+			signer, err := loadIdentityFile(path)
+			if err != nil {
+				// Default identity files are optional and could be protected by a passphrase
+				continue
+			}
+
+			signers = append(signers, signer)
+		}
+
+		return signers, nil
+	}
+
+	for _, path := range identityFiles {
+		signer, err := loadIdentityFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		signers = append(signers, signer)
+	}
+
+	return signers, nil
+}
+
+func loadIdentityFile(path string) (gossh.Signer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read SSH identity file '%s': %v", path, err)
+	}
+
+	signer, err := gossh.ParsePrivateKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse SSH identity file '%s' (passphrase protected keys have to be added to your SSH agent): %v", path, err)
+	}
+
+	return signer, nil
+}
+
+// getKnownHostsCallback returns a callback verifying host keys against the given known_hosts file
+func getKnownHostsCallback(path string) (gossh.HostKeyCallback, error) {
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load SSH known hosts file '%s' (connect once with ssh to add the host key): %v", path, err)
+	}
+
+	return callback, nil
+}
+
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[2:])
+	}
+
+	return path
+}
@@ -2,40 +2,68 @@ package ssh
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io"
+	"net"
+	"sync"
+	"time"
 
+	"github.com/eko/monday/pkg/config"
 	"github.com/eko/monday/pkg/ui"
+	gossh "golang.org/x/crypto/ssh"
+)
 
-	"github.com/eko/monday/pkg/config"
+const (
+	// defaultForwardHostname is the SSH forward hostname used if none specified in config
+	defaultForwardHostname = "127.0.0.1"
+
+	dialTimeout = 10 * time.Second
+)
+
+var (
+	// keepAliveInterval is the delay between two keepalive requests sent to the SSH server
+	keepAliveInterval = 5 * time.Second
+
+	// keepAliveMaxFailures is the number of unanswered keepalive requests after which the tunnel is considered dead
+	keepAliveMaxFailures = 3
+
+	errStopped = errors.New("forwarder has been stopped")
 )
 
+// Mapping represents a port forwarded over the SSH connection. For a local forward, the local port
+// is listened locally and forwarded to the forward port on the remote side. For a remote forward,
+// the local port is listened on the remote side and forwarded to the forward port locally.
+type Mapping struct {
+	LocalPort   string
+	ForwardPort string
+}
+
 type Forwarder struct {
 	view            ui.View
 	forwardType     string
-	remote          string
+	values          config.ForwardValues
 	forwardHostname string
-	localPort       string
-	forwardPort     string
-	args            []string
-	cmd             *exec.Cmd
+	mappings        []Mapping
+	proxyKey        *ProxyKey
+	keepAlive       time.Duration
+	client          *gossh.Client
+	listeners       []net.Listener
+	stopped         bool
+	mux             sync.Mutex
 	stopChannel     chan struct{}
 	readyChannel    chan struct{}
 }
 
-var (
-	execCommand = exec.Command
-)
-
-func NewForwarder(view ui.View, forwardType string, values config.ForwardValues, localPort, forwardPort string) (*Forwarder, error) {
+func NewForwarder(view ui.View, forwardType string, values config.ForwardValues, mappings []Mapping) (*Forwarder, error) {
 	return &Forwarder{
 		view:            view,
 		forwardType:     forwardType,
-		remote:          values.Remote,
+		values:          values,
 		forwardHostname: values.ForwardHostname,
-		localPort:       localPort,
-		forwardPort:     forwardPort,
-		args:            values.Args,
+		mappings:        mappings,
+		keepAlive:       keepAliveInterval,
+		listeners:       make([]net.Listener, 0),
 		stopChannel:     make(chan struct{}),
 		readyChannel:    make(chan struct{}, 1),
 	}, nil
@@ -56,60 +84,303 @@ func (f *Forwarder) GetStopChannel() chan struct{} {
 	return f.readyChannel
 }
 
-func (f *Forwarder) Forward(_ context.Context) error {
-	if f.remote == "" {
+// SetProxyKey is used when connecting to the Monday proxy deployed in a Kubernetes cluster: the connection
+// is authenticated with the given session key only and the proxy host key is not verified
+func (f *Forwarder) SetProxyKey(key *ProxyKey) {
+	f.proxyKey = key
+}
+
+// Forward opens a single SSH connection and forwards all the ports over it. It blocks until the
+// connection is lost or the forwarder is stopped.
+func (f *Forwarder) Forward(ctx context.Context) error {
+	if f.values.Remote == "" {
 		return fmt.Errorf("Please provide a 'remote' attribute specifing the host you want to SSH on")
 	}
 
-	var forwardOption string
+	if f.isStopped() {
+		<-ctx.Done()
+		return nil
+	}
 
-	switch f.forwardType {
-	case config.ForwarderSSH:
-		forwardOption = "-L"
-	case config.ForwarderSSHRemote:
-		forwardOption = "-R"
+	options, err := parseOptions(f.values)
+	if err != nil {
+		return err
 	}
 
-	var forwardHostname = "127.0.0.1" // Default SSH forward hostname if none specified in config
-	if f.forwardHostname != "" {
-		forwardHostname = f.forwardHostname
+	for _, arg := range options.ignoredArgs {
+		f.view.Writef("⚠️  SSH argument '%s' is not supported and will be ignored\n", arg)
 	}
 
-	mapping := fmt.Sprintf("%s:%s:%s", f.localPort, forwardHostname, f.forwardPort)
-	host := f.remote
+	clientConfig, closeAuth, err := f.newClientConfig(options)
+	if err != nil {
+		return err
+	}
+	defer closeAuth()
 
-	arguments := append([]string{
-		"-oUserKnownHostsFile=/dev/null",
-		"-oStrictHostKeyChecking=no",
-		"-N",
-		forwardOption,
-		mapping,
-		host,
-	}, f.args...)
+	address := options.address()
 
-	f.cmd = execCommand("ssh", arguments...)
+	client, closeClients, err := dial(options, clientConfig)
+	if err != nil {
+		return err
+	}
+	defer closeClients()
 
-	if err := f.cmd.Start(); err != nil {
-		return fmt.Errorf("Cannot run the SSH command for port-forwarding '%s' on host '%s': %v", mapping, host, err)
+	if err := f.setClient(client); err != nil {
+		return nil
 	}
 
-	if err := f.cmd.Wait(); err != nil {
-		return fmt.Errorf("SSH forwarding of '%s' on host '%s' returned an error: %v", mapping, host, err)
+	for _, mapping := range f.mappings {
+		if err := f.forwardMapping(client, mapping); err != nil {
+			f.closeListeners()
+			return err
+		}
 	}
+	defer f.closeListeners()
 
-	return nil
+	// Notify that forwarding is ready, without blocking in case nobody is listening
+	select {
+	case f.readyChannel <- struct{}{}:
+	default:
+	}
+
+	dead := make(chan struct{})
+	go f.sendKeepAlives(client, dead)
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- client.Wait()
+	}()
+
+	select {
+	case <-f.stopChannel:
+		return nil
+	case <-dead:
+		return fmt.Errorf("SSH connection on host '%s' is dead (no answer to %d keepalive requests)", address, keepAliveMaxFailures)
+	case err := <-closed:
+		if f.isStopped() {
+			return nil
+		}
+		return fmt.Errorf("SSH connection on host '%s' has been closed: %v", address, err)
+	}
+}
+
+// dial opens the SSH connection, going through each jump host in order. The returned function
+// closes the connection and the jump host ones.
+func dial(opts *options, clientConfig *gossh.ClientConfig) (*gossh.Client, func(), error) {
+	hops := append(append([]endpoint{}, opts.jumpHosts...), opts.endpoint)
+	clients := make([]*gossh.Client, 0, len(hops))
+
+	closeClients := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+	}
+
+	for _, hop := range hops {
+		hopConfig := *clientConfig
+		hopConfig.User = hop.user
+
+		var client *gossh.Client
+
+		if len(clients) == 0 {
+			var err error
+
+			client, err = gossh.Dial("tcp", hop.address(), &hopConfig)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Cannot open SSH connection on host '%s': %v", hop.address(), err)
+			}
+		} else {
+			// Open the connection through the previous jump host
+			conn, err := clients[len(clients)-1].Dial("tcp", hop.address())
+			if err != nil {
+				closeClients()
+				return nil, nil, fmt.Errorf("Cannot reach host '%s' through jump host '%s': %v", hop.address(), hops[len(clients)-1].address(), err)
+			}
+
+			clientConn, channels, requests, err := gossh.NewClientConn(conn, hop.address(), &hopConfig)
+			if err != nil {
+				conn.Close()
+				closeClients()
+				return nil, nil, fmt.Errorf("Cannot open SSH connection on host '%s': %v", hop.address(), err)
+			}
+
+			client = gossh.NewClient(clientConn, channels, requests)
+		}
+
+		clients = append(clients, client)
+	}
+
+	return clients[len(clients)-1], closeClients, nil
 }
 
 // Stop stops the current forwarder
 func (f *Forwarder) Stop(_ context.Context) error {
-	if f.cmd == nil {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.stopped {
 		return nil
 	}
 
-	err := f.cmd.Process.Kill()
+	f.stopped = true
+	close(f.stopChannel)
+
+	for _, listener := range f.listeners {
+		listener.Close()
+	}
+
+	if f.client != nil {
+		return f.client.Close()
+	}
+
+	return nil
+}
+
+func (f *Forwarder) isStopped() bool {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	return f.stopped
+}
+
+func (f *Forwarder) setClient(client *gossh.Client) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if f.stopped {
+		return errStopped
+	}
+
+	f.client = client
+
+	return nil
+}
+
+func (f *Forwarder) addListener(listener net.Listener) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	f.listeners = append(f.listeners, listener)
+}
+
+func (f *Forwarder) closeListeners() {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	for _, listener := range f.listeners {
+		listener.Close()
+	}
+
+	f.listeners = make([]net.Listener, 0)
+}
+
+func (f *Forwarder) getForwardAddress(mapping Mapping) string {
+	var forwardHostname = defaultForwardHostname
+	if f.forwardHostname != "" {
+		forwardHostname = f.forwardHostname
+	}
+
+	return net.JoinHostPort(forwardHostname, mapping.ForwardPort)
+}
+
+// forwardMapping listens on the local port (locally for a local forward, on the remote side
+// for a remote forward) and proxifies accepted connections through the SSH connection
+func (f *Forwarder) forwardMapping(client *gossh.Client, mapping Mapping) error {
+	var listener net.Listener
+	var dial func(network, address string) (net.Conn, error)
+	var err error
+
+	listenAddress := net.JoinHostPort(defaultForwardHostname, mapping.LocalPort)
+	forwardAddress := f.getForwardAddress(mapping)
+
+	switch f.forwardType {
+	case config.ForwarderSSHRemote:
+		listener, err = client.Listen("tcp", listenAddress)
+		dial = net.Dial
+	default:
+		listener, err = net.Listen("tcp", listenAddress)
+		dial = client.Dial
+	}
+
 	if err != nil {
-		return err
+		return fmt.Errorf("Cannot listen on '%s' for SSH forwarding to '%s': %v", listenAddress, forwardAddress, err)
 	}
 
+	f.addListener(listener)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go f.handleConnection(conn, dial, forwardAddress)
+		}
+	}()
+
 	return nil
 }
+
+func (f *Forwarder) handleConnection(conn net.Conn, dial func(network, address string) (net.Conn, error), address string) {
+	defer conn.Close()
+
+	target, err := dial("tcp", address)
+	if err != nil {
+		f.view.Writef("❌  Error when dialing with SSH forward target '%s': %v\n", address, err)
+		return
+	}
+	defer target.Close()
+
+	done := make(chan struct{}, 2)
+
+	go func() {
+		io.Copy(target, conn)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		io.Copy(conn, target)
+		done <- struct{}{}
+	}()
+
+	<-done
+}
+
+// sendKeepAlives sends keepalive requests to the SSH server and closes the given channel
+// as soon as too many requests stay unanswered
+func (f *Forwarder) sendKeepAlives(client *gossh.Client, dead chan<- struct{}) {
+	failures := 0
+
+	for {
+		select {
+		case <-f.stopChannel:
+			return
+		case <-time.After(f.keepAlive):
+		}
+
+		answered := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			answered <- err
+		}()
+
+		select {
+		case err := <-answered:
+			if err != nil {
+				// Connection is closed, client.Wait() will return
+				return
+			}
+			failures = 0
+			continue
+		case <-time.After(f.keepAlive):
+		}
+
+		failures++
+		if failures >= keepAliveMaxFailures {
+			client.Close()
+			close(dead)
+			return
+		}
+	}
+}
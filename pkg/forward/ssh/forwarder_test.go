@@ -2,14 +2,23 @@ package ssh
 
 import (
 	"context"
-	"os/exec"
-	"strings"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/eko/monday/pkg/config"
 	"github.com/eko/monday/pkg/ui"
-	"go.uber.org/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 func TestNewForwarder(t *testing.T) {
@@ -17,8 +26,7 @@ func TestNewForwarder(t *testing.T) {
 	defer ctrl.Finish()
 
 	// Given
-	localPort := "8080"
-	forwardPort := "8081"
+	mappings := []Mapping{{LocalPort: "8080", ForwardPort: "8081"}}
 
 	values := config.ForwardValues{
 		Remote: "root@acme.tld",
@@ -28,19 +36,17 @@ func TestNewForwarder(t *testing.T) {
 	view := ui.NewMockView(ctrl)
 
 	// When
-	forwarder, err := NewForwarder(view, config.ForwarderSSH, values, localPort, forwardPort)
+	forwarder, err := NewForwarder(view, config.ForwarderSSH, values, mappings)
 
 	// Then
 	assert.IsType(t, new(Forwarder), forwarder)
 	assert.Nil(t, err)
 
 	assert.Equal(t, config.ForwarderSSH, forwarder.forwardType)
-	assert.Equal(t, values.Remote, forwarder.remote)
-	assert.Equal(t, localPort, forwarder.localPort)
-	assert.Equal(t, forwardPort, forwarder.forwardPort)
-	assert.Equal(t, values.Args, forwarder.args)
+	assert.Equal(t, values, forwarder.values)
+	assert.Equal(t, mappings, forwarder.mappings)
 
-	assert.Nil(t, forwarder.cmd)
+	assert.Nil(t, forwarder.client)
 }
 
 func TestGetForwardType(t *testing.T) {
@@ -54,7 +60,7 @@ func TestGetForwardType(t *testing.T) {
 		Remote: "root@acme.tld",
 	}
 
-	forwarder, err := NewForwarder(view, config.ForwarderSSHRemote, values, "8080", "8081")
+	forwarder, err := NewForwarder(view, config.ForwarderSSHRemote, values, []Mapping{{LocalPort: "8080", ForwardPort: "8081"}})
 
 	// When
 	forwardType := forwarder.GetForwardType()
@@ -77,7 +83,7 @@ func TestGetReadyChannel(t *testing.T) {
 		Remote: "root@acme.tld",
 	}
 
-	forwarder, err := NewForwarder(view, config.ForwarderSSHRemote, values, "8080", "8081")
+	forwarder, err := NewForwarder(view, config.ForwarderSSHRemote, values, []Mapping{{LocalPort: "8080", ForwardPort: "8081"}})
 
 	// When
 	channel := forwarder.GetReadyChannel()
@@ -100,7 +106,7 @@ func TestGetStopChannel(t *testing.T) {
 		Remote: "root@acme.tld",
 	}
 
-	forwarder, err := NewForwarder(view, config.ForwarderSSHRemote, values, "8080", "8081")
+	forwarder, err := NewForwarder(view, config.ForwarderSSHRemote, values, []Mapping{{LocalPort: "8080", ForwardPort: "8081"}})
 
 	// When
 	channel := forwarder.GetStopChannel()
@@ -112,92 +118,493 @@ func TestGetStopChannel(t *testing.T) {
 	assert.IsType(t, make(chan struct{}), channel)
 }
 
+func TestParseOptions(t *testing.T) {
+	// Given
+	t.Setenv("HOME", "/home/monday")
+
+	// When
+	opts, err := parseOptions(config.ForwardValues{
+		Remote: "root@acme.tld:2222",
+		Args:   []string{"-p 2223", "-i", "~/.ssh/my.key", "-oStrictHostKeyChecking=no"},
+	})
+
+	// Then
+	assert.Nil(t, err)
+
+	assert.Equal(t, "root", opts.user)
+	assert.Equal(t, "acme.tld", opts.host)
+	assert.Equal(t, "2223", opts.port)
+	assert.Equal(t, []string{"/home/monday/.ssh/my.key"}, opts.identityFiles)
+	assert.Equal(t, []string{"-oStrictHostKeyChecking=no"}, opts.ignoredArgs)
+}
+
+func TestParseOptionsWithDefaults(t *testing.T) {
+	// When
+	opts, err := parseOptions(config.ForwardValues{
+		Remote: "acme.tld",
+		Args:   []string{"-l", "monday", "-i/tmp/my/private.key"},
+	})
+
+	// Then
+	assert.Nil(t, err)
+
+	assert.Equal(t, "monday", opts.user)
+	assert.Equal(t, "acme.tld", opts.host)
+	assert.Equal(t, "22", opts.port)
+	assert.Equal(t, []string{"/tmp/my/private.key"}, opts.identityFiles)
+	assert.Equal(t, filepath.Join(sshDirectory, "known_hosts"), opts.knownHosts)
+	assert.Len(t, opts.jumpHosts, 0)
+}
+
+func TestParseOptionsWithSSHValues(t *testing.T) {
+	// Given
+	t.Setenv("HOME", "/home/monday")
+
+	// When
+	opts, err := parseOptions(config.ForwardValues{
+		Remote:       "acme.tld",
+		Args:         []string{"-p 2222", "-i/tmp/my/private.key"},
+		User:         "monday",
+		Port:         "2223",
+		IdentityFile: "~/.ssh/monday.key",
+		KnownHosts:   "~/.ssh/monday_known_hosts",
+		JumpHosts:    []string{"bastion@bastion.acme.tld:2200", "internal.acme.tld"},
+	})
+
+	// Then
+	assert.Nil(t, err)
+
+	assert.Equal(t, "monday", opts.user)
+	assert.Equal(t, "acme.tld", opts.host)
+	assert.Equal(t, "2223", opts.port)
+	assert.Equal(t, []string{"/home/monday/.ssh/monday.key"}, opts.identityFiles)
+	assert.Equal(t, "/home/monday/.ssh/monday_known_hosts", opts.knownHosts)
+
+	assert.Len(t, opts.jumpHosts, 2)
+	assert.Equal(t, endpoint{user: "bastion", host: "bastion.acme.tld", port: "2200"}, opts.jumpHosts[0])
+	assert.Equal(t, "internal.acme.tld", opts.jumpHosts[1].host)
+	assert.Equal(t, "22", opts.jumpHosts[1].port)
+	assert.NotEmpty(t, opts.jumpHosts[1].user)
+}
+
+func TestParseOptionsWhenValueIsMissing(t *testing.T) {
+	// When
+	opts, err := parseOptions(config.ForwardValues{
+		Remote: "root@acme.tld",
+		Args:   []string{"-p"},
+	})
+
+	// Then
+	assert.Nil(t, opts)
+	assert.EqualError(t, err, "Missing value for SSH argument '-p'")
+}
+
+func TestForwardWhenNoRemote(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder, _ := NewForwarder(view, config.ForwarderSSH, config.ForwardValues{}, []Mapping{{LocalPort: "8080", ForwardPort: "8081"}})
+
+	// When
+	err := forwarder.Forward(context.Background())
+
+	// Then
+	assert.EqualError(t, err, "Please provide a 'remote' attribute specifing the host you want to SSH on")
+}
+
 func TestForwardLocal(t *testing.T) {
 	// Given
-	ctx := context.Background()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	execCommand = mockExecCommand
+	server := setupTestServer(t, true)
+
+	firstPort, secondPort := getFreePort(t), getFreePort(t)
 
 	view := ui.NewMockView(ctrl)
 
-	values := config.ForwardValues{
-		Remote:          "root@acme.tld",
-		ForwardHostname: "myforwardhostname.svc.local",
-	}
+	forwarder, _ := NewForwarder(view, config.ForwarderSSH, config.ForwardValues{
+		Remote: "monday@" + server.address(),
+	}, []Mapping{
+		{LocalPort: firstPort, ForwardPort: newEchoServer(t)},
+		{LocalPort: secondPort, ForwardPort: newEchoServer(t)},
+	})
+
+	// When
+	result := startForwarder(t, forwarder)
+
+	// Then
+	assertEcho(t, net.JoinHostPort("127.0.0.1", firstPort))
+	assertEcho(t, net.JoinHostPort("127.0.0.1", secondPort))
 
-	forwarder, err := NewForwarder(view, config.ForwarderSSH, values, "8080", "8081")
+	// All ports are forwarded over a single connection
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.connections))
+
+	forwarder.Stop(context.Background())
+	assert.Nil(t, <-result)
+}
+
+func TestForwardRemote(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := setupTestServer(t, true)
+
+	remotePort := getFreePort(t)
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder, _ := NewForwarder(view, config.ForwarderSSHRemote, config.ForwardValues{
+		Remote: "monday@" + server.address(),
+	}, []Mapping{
+		{LocalPort: remotePort, ForwardPort: newEchoServer(t)},
+	})
 
 	// When
-	err = forwarder.Forward(ctx)
+	result := startForwarder(t, forwarder)
 
 	// Then
-	assert.Nil(t, err)
+	assertEcho(t, net.JoinHostPort("127.0.0.1", remotePort))
 
-	assert.IsType(t, new(exec.Cmd), forwarder.cmd)
+	forwarder.Stop(context.Background())
+	assert.Nil(t, <-result)
+}
 
-	runCommand := strings.Replace(strings.Join(forwarder.cmd.Args, " "), "echo <ssh>", "ssh", -1)
-	assert.Equal(t, "ssh -oUserKnownHostsFile=/dev/null -oStrictHostKeyChecking=no -N -L 8080:myforwardhostname.svc.local:8081 root@acme.tld", runCommand)
+func TestForwardWithAgent(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := setupTestServer(t, false)
+
+	localPort := getFreePort(t)
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder, _ := NewForwarder(view, config.ForwarderSSH, config.ForwardValues{
+		Remote: "monday@" + server.address(),
+	}, []Mapping{
+		{LocalPort: localPort, ForwardPort: newEchoServer(t)},
+	})
+
+	// When
+	result := startForwarder(t, forwarder)
+
+	// Then
+	assertEcho(t, net.JoinHostPort("127.0.0.1", localPort))
+
+	forwarder.Stop(context.Background())
+	assert.Nil(t, <-result)
 }
 
-func TestForwardLocalWithForwardHostname(t *testing.T) {
+func TestForwardWithProxyKey(t *testing.T) {
 	// Given
-	ctx := context.Background()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	execCommand = mockExecCommand
+	proxyKey, err := NewProxyKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorizedKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(proxyKey.AuthorizedKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Proxy host key is not in known hosts and user keys are not authorized on it
+	setupTestServer(t, true)
+	server := newTestServer(t, authorizedKey)
+
+	remotePort := getFreePort(t)
 
 	view := ui.NewMockView(ctrl)
 
-	values := config.ForwardValues{
-		Remote: "root@acme.tld",
+	forwarder, _ := NewForwarder(view, config.ForwarderSSHRemote, config.ForwardValues{
+		Remote: "root@" + server.address(),
+	}, []Mapping{
+		{LocalPort: remotePort, ForwardPort: newEchoServer(t)},
+	})
+	forwarder.SetProxyKey(proxyKey)
+
+	// When
+	result := startForwarder(t, forwarder)
+
+	// Then
+	assertEcho(t, net.JoinHostPort("127.0.0.1", remotePort))
+
+	forwarder.Stop(context.Background())
+	assert.Nil(t, <-result)
+}
+
+func TestForwardWithProxyKeyWhenNotAuthorized(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	proxyKey, err := NewProxyKey()
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	forwarder, err := NewForwarder(view, config.ForwarderSSH, values, "8080", "8081")
+	// Server only authorizes the user key, which must not be used to connect on the proxy
+	server := setupTestServer(t, true)
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder, _ := NewForwarder(view, config.ForwarderSSHRemote, config.ForwardValues{
+		Remote: "root@" + server.address(),
+	}, []Mapping{
+		{LocalPort: getFreePort(t), ForwardPort: newEchoServer(t)},
+	})
+	forwarder.SetProxyKey(proxyKey)
 
 	// When
-	err = forwarder.Forward(ctx)
+	err = forwarder.Forward(context.Background())
 
 	// Then
-	assert.Nil(t, err)
+	assert.Contains(t, err.Error(), "unable to authenticate")
+}
 
-	assert.IsType(t, new(exec.Cmd), forwarder.cmd)
+func TestForwardThroughJumpHosts(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := setupTestServer(t, true)
+	firstJumpHost := newTestServer(t, server.authorizedKey)
+	firstJumpHost.writeKnownHosts(sshDirectory)
+	secondJumpHost := newTestServer(t, server.authorizedKey)
+	secondJumpHost.writeKnownHosts(sshDirectory)
+
+	localPort := getFreePort(t)
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder, _ := NewForwarder(view, config.ForwarderSSH, config.ForwardValues{
+		Remote:    server.address(),
+		User:      "monday",
+		JumpHosts: []string{"bastion@" + firstJumpHost.address(), "bastion@" + secondJumpHost.address()},
+	}, []Mapping{
+		{LocalPort: localPort, ForwardPort: newEchoServer(t)},
+	})
+
+	// When
+	result := startForwarder(t, forwarder)
+
+	// Then
+	assertEcho(t, net.JoinHostPort("127.0.0.1", localPort))
 
-	runCommand := strings.Replace(strings.Join(forwarder.cmd.Args, " "), "echo <ssh>", "ssh", -1)
-	assert.Equal(t, "ssh -oUserKnownHostsFile=/dev/null -oStrictHostKeyChecking=no -N -L 8080:127.0.0.1:8081 root@acme.tld", runCommand)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&firstJumpHost.connections))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&secondJumpHost.connections))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.connections))
+
+	forwarder.Stop(context.Background())
+	assert.Nil(t, <-result)
 }
 
-func TestForwardRemote(t *testing.T) {
+func TestForwardWithIdentityFileAndKnownHosts(t *testing.T) {
 	// Given
-	ctx := context.Background()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	execCommand = mockExecCommand
+	server := setupTestServer(t, true)
+
+	// Move identity and known hosts files to custom locations
+	directory := t.TempDir()
+	os.Rename(filepath.Join(sshDirectory, "id_ed25519"), filepath.Join(directory, "monday.key"))
+	os.Rename(filepath.Join(sshDirectory, "known_hosts"), filepath.Join(directory, "known_hosts"))
+
+	localPort := getFreePort(t)
 
 	view := ui.NewMockView(ctrl)
 
-	values := config.ForwardValues{
-		Remote: "root@acme.tld",
-	}
+	forwarder, _ := NewForwarder(view, config.ForwarderSSH, config.ForwardValues{
+		Remote:       server.address(),
+		IdentityFile: filepath.Join(directory, "monday.key"),
+		KnownHosts:   filepath.Join(directory, "known_hosts"),
+	}, []Mapping{
+		{LocalPort: localPort, ForwardPort: newEchoServer(t)},
+	})
+
+	// When
+	result := startForwarder(t, forwarder)
+
+	// Then
+	assertEcho(t, net.JoinHostPort("127.0.0.1", localPort))
 
-	forwarder, err := NewForwarder(view, config.ForwarderSSHRemote, values, "8080", "8081")
+	forwarder.Stop(context.Background())
+	assert.Nil(t, <-result)
+}
+
+func TestForwardWhenHostKeyIsUnknown(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := setupTestServer(t, true)
+
+	// Known hosts file does not contain the server host key anymore
+	os.WriteFile(filepath.Join(sshDirectory, "known_hosts"), []byte{}, 0600)
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder, _ := NewForwarder(view, config.ForwarderSSH, config.ForwardValues{
+		Remote: "monday@" + server.address(),
+	}, []Mapping{
+		{LocalPort: getFreePort(t), ForwardPort: "8080"},
+	})
 
 	// When
-	err = forwarder.Forward(ctx)
+	err := forwarder.Forward(context.Background())
 
 	// Then
-	assert.Nil(t, err)
+	assert.ErrorContains(t, err, "knownhosts: key is unknown")
+}
+
+func TestForwardWhenTunnelIsDead(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defaultKeepAliveInterval := keepAliveInterval
+	keepAliveInterval = 50 * time.Millisecond
+	defer func() {
+		keepAliveInterval = defaultKeepAliveInterval
+	}()
+
+	server := setupTestServer(t, true)
+
+	view := ui.NewMockView(ctrl)
+
+	forwarder, _ := NewForwarder(view, config.ForwarderSSH, config.ForwardValues{
+		Remote: "monday@" + server.address(),
+	}, []Mapping{
+		{LocalPort: getFreePort(t), ForwardPort: newEchoServer(t)},
+	})
+
+	result := startForwarder(t, forwarder)
+
+	// When
+	atomic.StoreInt32(&server.ignoreKeepAlive, 1)
+
+	// Then
+	select {
+	case err := <-result:
+		assert.ErrorContains(t, err, "is dead (no answer to 3 keepalive requests)")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Dead tunnel has not been detected")
+	}
+}
+
+// setupTestServer starts an in-process SSH server and configures the client authentication,
+// either with an identity file or with an SSH agent
+func setupTestServer(t *testing.T, withIdentityFile bool) *testServer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := gossh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaultSSHDirectory := sshDirectory
+	sshDirectory = t.TempDir()
+	t.Cleanup(func() {
+		sshDirectory = defaultSSHDirectory
+	})
+
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	if withIdentityFile {
+		block, err := gossh.MarshalPrivateKey(privateKey, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(sshDirectory, "id_ed25519"), pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		t.Setenv("SSH_AUTH_SOCK", startAgent(t, privateKey))
+	}
 
-	assert.IsType(t, new(exec.Cmd), forwarder.cmd)
+	server := newTestServer(t, signer.PublicKey())
+	server.writeKnownHosts(sshDirectory)
 
-	runCommand := strings.Replace(strings.Join(forwarder.cmd.Args, " "), "echo <ssh>", "ssh", -1)
-	assert.Equal(t, "ssh -oUserKnownHostsFile=/dev/null -oStrictHostKeyChecking=no -N -R 8080:127.0.0.1:8081 root@acme.tld", runCommand)
+	return server
 }
 
-func mockExecCommand(command string, args ...string) *exec.Cmd {
-	args = append([]string{"<ssh>"}, args...)
-	return exec.Command("echo", args...)
+// startAgent serves an SSH agent holding the given key on a unix socket
+func startAgent(t *testing.T, privateKey ed25519.PrivateKey) string {
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: privateKey}); err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+
+	return socket
+}
+
+// startForwarder runs the forwarder and waits for it to be ready
+func startForwarder(t *testing.T, forwarder *Forwarder) <-chan error {
+	result := make(chan error, 1)
+
+	go func() {
+		result <- forwarder.Forward(context.Background())
+	}()
+
+	select {
+	case <-forwarder.GetReadyChannel():
+	case err := <-result:
+		t.Fatalf("Forwarder has returned before being ready: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Forwarder is not ready")
+	}
+
+	return result
+}
+
+func assertEcho(t *testing.T, address string) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello monday"))
+	assert.Nil(t, err)
+
+	buffer := make([]byte, len("hello monday"))
+	_, err = io.ReadFull(conn, buffer)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "hello monday", string(buffer))
 }
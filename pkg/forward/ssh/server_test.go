@@ -0,0 +1,298 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testServer is an in-process SSH server supporting local and remote port-forwarding
+type testServer struct {
+	t                  *testing.T
+	listener           net.Listener
+	config             *gossh.ServerConfig
+	authorizedKey      gossh.PublicKey
+	hostKey            gossh.Signer
+	connections        int32
+	ignoreKeepAlive    int32
+	remoteListeners    []net.Listener
+	remoteListenersMux sync.Mutex
+}
+
+// newTestServer starts an SSH server accepting the given authorized public key
+func newTestServer(t *testing.T, authorizedKey gossh.PublicKey) *testServer {
+	_, hostPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostKey, err := gossh.NewSignerFromKey(hostPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &gossh.ServerConfig{
+		PublicKeyCallback: func(conn gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			if string(key.Marshal()) == string(authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{
+		t:             t,
+		listener:      listener,
+		config:        config,
+		authorizedKey: authorizedKey,
+		hostKey:       hostKey,
+	}
+
+	go s.serve()
+
+	t.Cleanup(s.close)
+
+	return s
+}
+
+func (s *testServer) address() string {
+	return s.listener.Addr().String()
+}
+
+// writeKnownHosts adds the server host key into the known_hosts file of the given directory
+func (s *testServer) writeKnownHosts(directory string) {
+	line := knownhosts.Line([]string{knownhosts.Normalize(s.address())}, s.hostKey.PublicKey())
+
+	file, err := os.OpenFile(filepath.Join(directory, "known_hosts"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *testServer) close() {
+	s.listener.Close()
+
+	s.remoteListenersMux.Lock()
+	defer s.remoteListenersMux.Unlock()
+
+	for _, listener := range s.remoteListeners {
+		listener.Close()
+	}
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *testServer) handle(netConn net.Conn) {
+	conn, channels, requests, err := gossh.NewServerConn(netConn, s.config)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	atomic.AddInt32(&s.connections, 1)
+
+	go s.handleRequests(conn, requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(gossh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := gossh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(gossh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			newChannel.Reject(gossh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go gossh.DiscardRequests(channelRequests)
+
+		go pipe(channel, target)
+	}
+}
+
+func (s *testServer) handleRequests(conn *gossh.ServerConn, requests <-chan *gossh.Request) {
+	for request := range requests {
+		switch request.Type {
+		case "keepalive@openssh.com":
+			if atomic.LoadInt32(&s.ignoreKeepAlive) == 1 {
+				continue
+			}
+			request.Reply(true, nil)
+
+		case "tcpip-forward":
+			var payload struct {
+				Address string
+				Port    uint32
+			}
+			if err := gossh.Unmarshal(request.Payload, &payload); err != nil {
+				request.Reply(false, nil)
+				continue
+			}
+
+			listener, err := net.Listen("tcp", net.JoinHostPort(payload.Address, strconv.Itoa(int(payload.Port))))
+			if err != nil {
+				request.Reply(false, nil)
+				continue
+			}
+
+			s.remoteListenersMux.Lock()
+			s.remoteListeners = append(s.remoteListeners, listener)
+			s.remoteListenersMux.Unlock()
+
+			port := uint32(listener.Addr().(*net.TCPAddr).Port)
+			response := make([]byte, 4)
+			binary.BigEndian.PutUint32(response, port)
+			request.Reply(true, response)
+
+			go s.forwardRemote(conn, listener, payload.Address, port)
+
+		default:
+			if request.WantReply {
+				request.Reply(false, nil)
+			}
+		}
+	}
+}
+
+func (s *testServer) forwardRemote(conn *gossh.ServerConn, listener net.Listener, address string, port uint32) {
+	go func() {
+		conn.Wait()
+		listener.Close()
+	}()
+
+	for {
+		client, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		origin := client.RemoteAddr().(*net.TCPAddr)
+
+		payload := gossh.Marshal(&struct {
+			Address    string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}{address, port, origin.IP.String(), uint32(origin.Port)})
+
+		channel, requests, err := conn.OpenChannel("forwarded-tcpip", payload)
+		if err != nil {
+			client.Close()
+			continue
+		}
+		go gossh.DiscardRequests(requests)
+
+		go pipe(channel, client)
+	}
+}
+
+func pipe(a io.ReadWriteCloser, b io.ReadWriteCloser) {
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan struct{}, 2)
+
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+
+	<-done
+}
+
+// newEchoServer starts a TCP server writing back everything it receives
+func newEchoServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return portOf(listener.Addr().String())
+}
+
+// getFreePort returns a local port that is not used at the moment
+func getFreePort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	return portOf(listener.Addr().String())
+}
+
+func portOf(address string) string {
+	_, port, _ := net.SplitHostPort(address)
+	return port
+}
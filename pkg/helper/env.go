@@ -2,10 +2,14 @@ package helper
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/kubernetes/env"
 )
 
 // AddEnvVariables adds environment variables given as key/value pair
@@ -15,6 +19,22 @@ func AddEnvVariables(cmd *exec.Cmd, envs map[string]string) {
 	}
 }
 
+// AddEnvVariablesFromCluster adds environment variables read from Kubernetes ConfigMaps and Secrets
+func AddEnvVariablesFromCluster(cmd *exec.Cmd, envFrom []*config.EnvFrom) error {
+	if len(envFrom) == 0 {
+		return nil
+	}
+
+	envs, err := env.GetEnvVariables(context.Background(), envFrom)
+	if err != nil {
+		return fmt.Errorf("unable to read environment variables from cluster: %v", err)
+	}
+
+	AddEnvVariables(cmd, envs)
+
+	return nil
+}
+
 // AddEnvVariablesFromFile adds environment variables given as a filename
 func AddEnvVariablesFromFile(cmd *exec.Cmd, filename string) error {
 	if filename == "" {
@@ -38,6 +38,18 @@ func TestAddEnvVariablesFromFile(t *testing.T) {
 	assert.Contains(t, cmd.Env, "MY_ENVFILE_VAR_3=great")
 }
 
+func TestAddEnvVariablesFromClusterWhenNone(t *testing.T) {
+	// Given
+	cmd := &exec.Cmd{}
+
+	// When
+	err := AddEnvVariablesFromCluster(cmd, nil)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Empty(t, cmd.Env)
+}
+
 func getMockedApplication() *config.Application {
 	dir, _ := os.Getwd()
 
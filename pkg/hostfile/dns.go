@@ -0,0 +1,301 @@
+package hostfile
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultUpstream is the resolver unknown names are sent to when none can be found in /etc/resolv.conf
+	DefaultUpstream = "8.8.8.8:53"
+
+	dnsPacketSize = 512
+
+	// maxDNSPacketSize is the largest UDP datagram, answers using EDNS0 can exceed dnsPacketSize
+	maxDNSPacketSize = 65535
+)
+
+var (
+	resolvConfPath = "/etc/resolv.conf"
+
+	// upstreamTimeout is the time given to the upstream resolver to answer a forwarded query
+	upstreamTimeout = 5 * time.Second
+)
+
+// dnsServer is an alternative to the hosts file: instead of writing hosts into /etc/hosts, it answers
+// the DNS queries of their hostnames (wildcards like *.svc.local included) and forwards the other
+// queries to the upstream resolver
+type dnsServer struct {
+	address  string
+	upstream string
+	hosts    map[string][]net.IP
+	conn     net.PacketConn
+	mux      sync.RWMutex
+}
+
+// NewDNSServer returns a new DNS server listening on the given address, forwarding unknown names to
+// the given upstream resolver (the first one of /etc/resolv.conf if empty)
+func NewDNSServer(address, upstream string) *dnsServer {
+	if upstream == "" {
+		upstream = getSystemUpstream(address)
+	}
+
+	return &dnsServer{
+		address:  address,
+		upstream: upstream,
+		hosts:    make(map[string][]net.IP),
+	}
+}
+
+// Listen starts answering DNS queries over UDP
+func (s *dnsServer) Listen() error {
+	conn, err := net.ListenPacket("udp", s.address)
+	if err != nil {
+		return fmt.Errorf("unable to start DNS server on '%s': %v", s.address, err)
+	}
+
+	s.mux.Lock()
+	s.conn = conn
+	s.mux.Unlock()
+
+	go s.serve(conn)
+
+	return nil
+}
+
+// Addr returns the address the DNS server is listening on
+func (s *dnsServer) Addr() net.Addr {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	return s.conn.LocalAddr()
+}
+
+// Close stops answering DNS queries
+func (s *dnsServer) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// AddHost answers the queries of the given hostname with the given IP address
+func (s *dnsServer) AddHost(ip, hostname string) error {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return fmt.Errorf("invalid IP address '%s' for hostname '%s'", ip, hostname)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	hostname = normalizeHostname(hostname)
+
+	for _, existing := range s.hosts[hostname] {
+		if existing.Equal(parsed) {
+			return nil
+		}
+	}
+
+	s.hosts[hostname] = append(s.hosts[hostname], parsed)
+
+	return nil
+}
+
+// RemoveHost stops answering the queries of the given hostname
+func (s *dnsServer) RemoveHost(hostname string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.hosts, normalizeHostname(hostname))
+
+	return nil
+}
+
+// lookup returns the IP addresses of the given hostname and whether it is managed by the server
+func (s *dnsServer) lookup(hostname string) ([]net.IP, bool) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	hostname = normalizeHostname(hostname)
+
+	if ips, ok := s.hosts[hostname]; ok {
+		return ips, true
+	}
+
+	for pattern, ips := range s.hosts {
+		if MatchHostname(pattern, hostname) {
+			return ips, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *dnsServer) serve(conn net.PacketConn) {
+	for {
+		buffer := make([]byte, maxDNSPacketSize)
+
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn, addr, buffer[:n])
+	}
+}
+
+func (s *dnsServer) handle(conn net.PacketConn, addr net.Addr, query []byte) {
+	response, err := s.answer(query)
+	if err != nil {
+		return
+	}
+
+	conn.WriteTo(response, addr)
+}
+
+// answer returns the response of the given query, from the managed hosts or the upstream resolver
+func (s *dnsServer) answer(query []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	ips, ok := s.lookup(question.Name.String())
+	if !ok {
+		return s.forward(query)
+	}
+
+	builder := dnsmessage.NewBuilder(make([]byte, 0, dnsPacketSize), dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+	})
+	builder.EnableCompression()
+
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	resource := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 0}
+
+	for _, ip := range ips {
+		switch {
+		case question.Type == dnsmessage.TypeA && ip.To4() != nil:
+			var a dnsmessage.AResource
+			copy(a.A[:], ip.To4())
+			err = builder.AResource(resource, a)
+
+		case question.Type == dnsmessage.TypeAAAA && ip.To4() == nil:
+			var aaaa dnsmessage.AAAAResource
+			copy(aaaa.AAAA[:], ip.To16())
+			err = builder.AAAAResource(resource, aaaa)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Finish()
+}
+
+// forward sends the given query to the upstream resolver and returns its response
+func (s *dnsServer) forward(query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", s.upstream, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(upstreamTimeout))
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, maxDNSPacketSize)
+
+	n, err := conn.Read(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response[:n], nil
+}
+
+// MatchHostname indicates if the given hostname matches the given pattern, which can be a wildcard
+// like *.svc.local matching all its subdomains
+func MatchHostname(pattern, hostname string) bool {
+	pattern = normalizeHostname(pattern)
+	hostname = normalizeHostname(hostname)
+
+	if !strings.HasPrefix(pattern, "*.") {
+		return pattern == hostname
+	}
+
+	return strings.HasSuffix(hostname, pattern[1:])
+}
+
+func normalizeHostname(hostname string) string {
+	return strings.TrimSuffix(strings.ToLower(hostname), ".")
+}
+
+// getSystemUpstream returns the first nameserver of /etc/resolv.conf which is not the given address
+// of the DNS server itself
+func getSystemUpstream(address string) string {
+	host, _, _ := net.SplitHostPort(address)
+
+	file, err := os.Open(resolvConfPath)
+	if err != nil {
+		return DefaultUpstream
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" || fields[1] == host {
+			continue
+		}
+
+		return net.JoinHostPort(fields[1], "53")
+	}
+
+	return DefaultUpstream
+}
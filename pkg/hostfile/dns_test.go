@@ -0,0 +1,176 @@
+package hostfile
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDNSServerAnswersHosts(t *testing.T) {
+	// Given
+	server := newTestDNSServer(t, DefaultUpstream)
+
+	server.AddHost("127.0.1.1", "graphql.svc.local")
+	server.AddHost("::127:0:1:1", "graphql.svc.local")
+
+	// When
+	ipv4 := queryDNS(t, server, "graphql.svc.local.", dnsmessage.TypeA)
+	ipv6 := queryDNS(t, server, "GraphQL.svc.local.", dnsmessage.TypeAAAA)
+
+	// Then
+	assert.Equal(t, []string{"127.0.1.1"}, ipv4)
+	assert.Equal(t, []string{"::127:0:1:1"}, ipv6)
+}
+
+func TestDNSServerAnswersWildcardHosts(t *testing.T) {
+	// Given
+	server := newTestDNSServer(t, DefaultUpstream)
+
+	server.AddHost("127.0.1.2", "*.svc.local")
+	server.AddHost("127.0.1.3", "user-api.svc.local")
+
+	// When
+	wildcard := queryDNS(t, server, "graphql.preprod.svc.local.", dnsmessage.TypeA)
+	exact := queryDNS(t, server, "user-api.svc.local.", dnsmessage.TypeA)
+
+	// Then
+	assert.Equal(t, []string{"127.0.1.2"}, wildcard)
+	assert.Equal(t, []string{"127.0.1.3"}, exact)
+}
+
+func TestDNSServerForwardsUnknownHosts(t *testing.T) {
+	// Given
+	upstream := newTestDNSServer(t, DefaultUpstream)
+	upstream.AddHost("93.184.216.34", "example.com")
+
+	server := newTestDNSServer(t, upstream.Addr().String())
+
+	server.AddHost("127.0.1.1", "graphql.svc.local")
+	server.RemoveHost("graphql.svc.local")
+
+	// When
+	forwarded := queryDNS(t, server, "example.com.", dnsmessage.TypeA)
+
+	// Then
+	assert.Equal(t, []string{"93.184.216.34"}, forwarded)
+}
+
+func TestDNSServerForwardsAnswersLargerThanPacketSize(t *testing.T) {
+	// Given
+	upstream := newTestDNSServer(t, DefaultUpstream)
+
+	expected := make([]string, 0, 64)
+	for i := 1; i <= 64; i++ {
+		ip := fmt.Sprintf("10.0.0.%d", i)
+		upstream.AddHost(ip, "example.com")
+		expected = append(expected, ip)
+	}
+
+	server := newTestDNSServer(t, upstream.Addr().String())
+
+	// When
+	forwarded := queryDNS(t, server, "example.com.", dnsmessage.TypeA)
+
+	// Then
+	assert.Equal(t, expected, forwarded)
+}
+
+func TestMatchHostname(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		hostname string
+		expected bool
+	}{
+		{pattern: "graphql.svc.local", hostname: "graphql.svc.local.", expected: true},
+		{pattern: "graphql.svc.local", hostname: "user-api.svc.local", expected: false},
+		{pattern: "*.svc.local", hostname: "graphql.svc.local", expected: true},
+		{pattern: "*.svc.local", hostname: "a.b.svc.local", expected: true},
+		{pattern: "*.svc.local", hostname: "svc.local", expected: false},
+		{pattern: "*.svc.local", hostname: "graphql.svc.localhost", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		// When - Then
+		assert.Equal(t, testCase.expected, MatchHostname(testCase.pattern, testCase.hostname), "%s matching %s", testCase.pattern, testCase.hostname)
+	}
+}
+
+func TestGetSystemUpstream(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	os.WriteFile(path, []byte("# Generated\nnameserver 127.0.0.1\nnameserver 10.0.0.2\n"), 0644)
+
+	original := resolvConfPath
+	resolvConfPath = path
+	defer func() { resolvConfPath = original }()
+
+	// When
+	upstream := getSystemUpstream("127.0.0.1:53")
+
+	// Then
+	assert.Equal(t, "10.0.0.2:53", upstream)
+}
+
+func newTestDNSServer(t *testing.T, upstream string) *dnsServer {
+	server := NewDNSServer("127.0.0.1:0", upstream)
+	if err := server.Listen(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	return server
+}
+
+// queryDNS sends a query of the given name to the given server and returns the IP addresses answered
+func queryDNS(t *testing.T, server *dnsServer, name string, queryType dnsmessage.Type) []string {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 42, RecursionDesired: true})
+	builder.StartQuestions()
+	builder.Question(dnsmessage.Question{Name: dnsmessage.MustNewName(name), Type: queryType, Class: dnsmessage.ClassINET})
+
+	query, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("udp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(query); err != nil {
+		t.Fatal(err)
+	}
+
+	response := make([]byte, maxDNSPacketSize)
+	n, err := conn.Read(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response[:n])
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(42), header.ID)
+
+	parser.SkipAllQuestions()
+	answers, err := parser.AllAnswers()
+	assert.Nil(t, err)
+
+	ips := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(body.AAAA[:]).String())
+		}
+	}
+
+	return ips
+}
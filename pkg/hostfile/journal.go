@@ -0,0 +1,34 @@
+package hostfile
+
+import "github.com/eko/monday/pkg/state"
+
+// journaledHostfile records hosts file changes into the current session journal
+// so they can be reverted in case Monday is not stopped properly
+type journaledHostfile struct {
+	hostfile Hostfile
+}
+
+// NewJournaledClient wraps the given hosts file manager client to record its changes
+func NewJournaledClient(hostfile Hostfile) *journaledHostfile {
+	return &journaledHostfile{
+		hostfile: hostfile,
+	}
+}
+
+// AddHost records the host into the session journal before adding it into the hosts file
+func (h *journaledHostfile) AddHost(ip, hostname string) error {
+	if err := state.Current.AddHost(ip, hostname); err != nil {
+		return err
+	}
+
+	return h.hostfile.AddHost(ip, hostname)
+}
+
+// RemoveHost removes the host from the hosts file and then from the session journal
+func (h *journaledHostfile) RemoveHost(hostname string) error {
+	if err := h.hostfile.RemoveHost(hostname); err != nil {
+		return err
+	}
+
+	return state.Current.RemoveHost(hostname)
+}
@@ -0,0 +1,182 @@
+package inspect
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR represents an HTTP Archive (version 1.2) of captured exchanges, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewHAR returns the given exchanges as an HTTP Archive, created by the given version of Monday
+func NewHAR(exchanges []*Exchange, version string) *HAR {
+	entries := make([]HAREntry, 0, len(exchanges))
+	for _, exchange := range exchanges {
+		entries = append(entries, newHAREntry(exchange))
+	}
+
+	return &HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "Monday", Version: version},
+			Entries: entries,
+		},
+	}
+}
+
+func newHAREntry(exchange *Exchange) HAREntry {
+	duration := float64(exchange.Duration) / float64(time.Millisecond)
+
+	entry := HAREntry{
+		StartedDateTime: exchange.StartedAt.Format(time.RFC3339Nano),
+		Time:            duration,
+		Request: HARRequest{
+			Method:      exchange.Method,
+			URL:         exchange.URL(),
+			HTTPVersion: exchange.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     newHARHeaders(exchange.RequestHeaders),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    exchange.RequestBodySize,
+		},
+		Response: HARResponse{
+			Status:      exchange.Status,
+			StatusText:  http.StatusText(exchange.Status),
+			HTTPVersion: exchange.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     newHARHeaders(exchange.ResponseHeaders),
+			Content: HARContent{
+				Size:     exchange.ResponseBodySize,
+				MimeType: exchange.ResponseHeaders.Get("Content-Type"),
+			},
+			RedirectURL: exchange.ResponseHeaders.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    exchange.ResponseBodySize,
+		},
+		Timings: HARTimings{Send: 0, Wait: duration, Receive: 0},
+		Comment: exchange.Error,
+	}
+
+	if parsed, err := url.ParseRequestURI(exchange.Path); err == nil {
+		for name, values := range parsed.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: name, Value: value})
+			}
+		}
+
+		sort.Slice(entry.Request.QueryString, func(a, b int) bool {
+			return entry.Request.QueryString[a].Name < entry.Request.QueryString[b].Name
+		})
+	}
+
+	if len(exchange.RequestBody) > 0 {
+		entry.Request.PostData = &HARPostData{
+			MimeType: exchange.RequestHeaders.Get("Content-Type"),
+			Text:     string(exchange.RequestBody),
+		}
+	}
+
+	if len(exchange.ResponseBody) > 0 {
+		// Binary (or compressed) bodies are kept encoded in base64
+		if utf8.Valid(exchange.ResponseBody) {
+			entry.Response.Content.Text = string(exchange.ResponseBody)
+		} else {
+			entry.Response.Content.Text = base64.StdEncoding.EncodeToString(exchange.ResponseBody)
+			entry.Response.Content.Encoding = "base64"
+		}
+	}
+
+	return entry
+}
+
+func newHARHeaders(headers http.Header) []HARNameValue {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]HARNameValue, 0, len(headers))
+	for _, name := range names {
+		for _, value := range headers[name] {
+			values = append(values, HARNameValue{Name: name, Value: value})
+		}
+	}
+
+	return values
+}
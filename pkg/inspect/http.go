@@ -0,0 +1,246 @@
+package inspect
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RoundTrip sends the given request to the server and returns its response
+type RoundTrip func(request *http.Request) (*http.Response, error)
+
+// Hook is called by ProxyHTTP with each request read from the client. It returns the response to write
+// to the client, usually the one given by sending the request with the round trip, and a function (which
+// can be nil) called once the exchange is over, with the error which occured if any.
+type Hook func(request *http.Request, roundTrip RoundTrip) (*http.Response, func(error), error)
+
+// ChainHooks returns a hook passing each request through the given hooks, the first one being called first
+func ChainHooks(hooks ...Hook) Hook {
+	chained := hooks[len(hooks)-1]
+
+	for index := len(hooks) - 2; index >= 0; index-- {
+		chained = chainHook(hooks[index], chained)
+	}
+
+	return chained
+}
+
+func chainHook(outer, inner Hook) Hook {
+	return func(request *http.Request, roundTrip RoundTrip) (*http.Response, func(error), error) {
+		var innerDone func(error)
+
+		response, outerDone, err := outer(request, func(request *http.Request) (*http.Response, error) {
+			response, done, err := inner(request, roundTrip)
+			innerDone = done
+
+			return response, err
+		})
+
+		done := func(err error) {
+			if innerDone != nil {
+				innerDone(err)
+			}
+
+			if outerDone != nil {
+				outerDone(err)
+			}
+		}
+
+		return response, done, err
+	}
+}
+
+// ProxyHTTP proxifies HTTP exchanges between the given client and server connections, passing each of
+// them through the given hook, until one of them closes the connection. Other protocols (like TLS) and
+// upgraded connections (like WebSockets) are then piped as is.
+func ProxyHTTP(client io.ReadWriter, server io.ReadWriter, hook Hook) {
+	clientReader := bufio.NewReader(client)
+	serverReader := bufio.NewReader(server)
+
+	// HTTP requests start with an uppercase method
+	if first, err := clientReader.Peek(1); err != nil {
+		return
+	} else if first[0] < 'A' || first[0] > 'Z' {
+		Pipe(client, clientReader, server, serverReader)
+		return
+	}
+
+	// readResponse returns the final response of the given request, informational ones (like 100 Continue
+	// or 103 Early Hints) are written to the client meanwhile
+	readResponse := func(request *http.Request) (*http.Response, error) {
+		for {
+			response, err := http.ReadResponse(serverReader, request)
+			if err != nil || !isInterimResponse(response) {
+				return response, err
+			}
+
+			if err := writeInterimResponse(client, response); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	roundTrip := func(request *http.Request) (*http.Response, error) {
+		// Go client default user agent must not be added to requests which do not have one
+		if _, ok := request.Header["User-Agent"]; !ok {
+			request.Header["User-Agent"] = []string{""}
+		}
+
+		if !expectsContinue(request) {
+			if err := request.Write(server); err != nil {
+				return nil, err
+			}
+
+			return readResponse(request)
+		}
+
+		// Client waits for 100 Continue before sending the body, which is only read once the server
+		// has answered it
+		body := newContinueBody(request.Body)
+		request.Body = body
+
+		written := make(chan error, 1)
+		go func() {
+			written <- request.Write(server)
+		}()
+
+		for {
+			response, err := http.ReadResponse(serverReader, request)
+			if err != nil {
+				body.proceed(false)
+				return nil, err
+			}
+
+			if response.StatusCode == http.StatusContinue {
+				if err := writeInterimResponse(client, response); err != nil {
+					body.proceed(false)
+					return nil, err
+				}
+
+				body.proceed(true)
+
+				if err := <-written; err != nil {
+					return nil, err
+				}
+
+				return readResponse(request)
+			}
+
+			if isInterimResponse(response) {
+				if err := writeInterimResponse(client, response); err != nil {
+					body.proceed(false)
+					return nil, err
+				}
+				continue
+			}
+
+			// Server answered without asking for the body: as the client could still send it, the
+			// connection cannot be used for other requests
+			body.proceed(false)
+			response.Close = true
+
+			return response, nil
+		}
+	}
+
+	for {
+		request, err := http.ReadRequest(clientReader)
+		if err != nil {
+			return
+		}
+
+		response, done, err := hook(request, roundTrip)
+		if err == nil {
+			err = response.Write(client)
+		}
+
+		if done != nil {
+			done(err)
+		}
+
+		if err != nil {
+			return
+		}
+
+		if response.StatusCode == http.StatusSwitchingProtocols {
+			Pipe(client, clientReader, server, serverReader)
+			return
+		}
+
+		if request.Close || response.Close {
+			return
+		}
+	}
+}
+
+func expectsContinue(request *http.Request) bool {
+	return strings.EqualFold(request.Header.Get("Expect"), "100-continue") && request.Body != nil && request.Body != http.NoBody
+}
+
+// isInterimResponse returns true for informational responses preceding the final one. Switching
+// protocols is a final response after which the connection is upgraded.
+func isInterimResponse(response *http.Response) bool {
+	return response.StatusCode >= 100 && response.StatusCode < 200 && response.StatusCode != http.StatusSwitchingProtocols
+}
+
+// writeInterimResponse writes the given informational response, which has neither a body nor a length
+func writeInterimResponse(w io.Writer, response *http.Response) error {
+	if _, err := fmt.Fprintf(w, "HTTP/%d.%d %s\r\n", response.ProtoMajor, response.ProtoMinor, response.Status); err != nil {
+		return err
+	}
+
+	if err := response.Header.Write(w); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\r\n")
+
+	return err
+}
+
+// continueBody is a request body which is only read once it is known whether it has to be sent
+type continueBody struct {
+	io.ReadCloser
+	decision chan bool
+	decided  sync.Once
+	send     bool
+}
+
+func newContinueBody(body io.ReadCloser) *continueBody {
+	return &continueBody{ReadCloser: body, decision: make(chan bool, 1)}
+}
+
+// proceed tells whether the body has to be sent, it must be called once
+func (b *continueBody) proceed(send bool) {
+	b.decision <- send
+}
+
+func (b *continueBody) Read(p []byte) (int, error) {
+	b.decided.Do(func() { b.send = <-b.decision })
+
+	if !b.send {
+		return 0, io.EOF
+	}
+
+	return b.ReadCloser.Read(p)
+}
+
+// Pipe copies data between the client and the server until one of them closes the connection
+func Pipe(client io.Writer, clientReader io.Reader, server io.Writer, serverReader io.Reader) {
+	done := make(chan struct{}, 2)
+
+	go func() {
+		io.Copy(server, clientReader)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		io.Copy(client, serverReader)
+		done <- struct{}{}
+	}()
+
+	<-done
+}
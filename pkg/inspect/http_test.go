@@ -0,0 +1,136 @@
+package inspect
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyHTTPWhenExpectContinue(t *testing.T) {
+	// Given
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s", r.URL.Path, body)
+	}))
+	defer target.Close()
+
+	server, err := net.Dial("tcp", strings.TrimPrefix(target.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, proxyClient := net.Pipe()
+	defer client.Close()
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// When
+	go ProxyHTTP(proxyClient, server, forwardHook)
+
+	reader := bufio.NewReader(client)
+
+	go fmt.Fprint(client, "POST /upload HTTP/1.1\r\nHost: api.svc.local\r\nExpect: 100-continue\r\nContent-Length: 5\r\n\r\n")
+
+	// Body is only sent once the server has asked for it
+	interim, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+
+	go fmt.Fprint(client, "hello")
+
+	response, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+
+	// Then
+	assert.Equal(t, http.StatusContinue, interim.StatusCode)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, "/upload hello", string(body))
+}
+
+func TestProxyHTTPWhenExpectContinueIsRefused(t *testing.T) {
+	// Given
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer target.Close()
+
+	server, err := net.Dial("tcp", strings.TrimPrefix(target.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, proxyClient := net.Pipe()
+	defer client.Close()
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// When
+	go ProxyHTTP(proxyClient, server, forwardHook)
+
+	go fmt.Fprint(client, "POST /upload HTTP/1.1\r\nHost: api.svc.local\r\nExpect: 100-continue\r\nContent-Length: 5\r\n\r\n")
+
+	response, err := http.ReadResponse(bufio.NewReader(client), nil)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+	assert.True(t, response.Close)
+}
+
+func TestProxyHTTPWhenEarlyHints(t *testing.T) {
+	// Given
+	client, proxyClient := net.Pipe()
+	proxyServer, server := net.Pipe()
+	defer client.Close()
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	go func() {
+		defer server.Close()
+
+		if _, err := http.ReadRequest(bufio.NewReader(server)); err != nil {
+			return
+		}
+
+		fmt.Fprint(server, "HTTP/1.1 103 Early Hints\r\nLink: </style.css>; rel=preload\r\n\r\n")
+		fmt.Fprint(server, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+	}()
+
+	// When
+	go ProxyHTTP(proxyClient, proxyServer, forwardHook)
+
+	reader := bufio.NewReader(client)
+
+	go fmt.Fprint(client, "GET / HTTP/1.1\r\nHost: api.svc.local\r\n\r\n")
+
+	interim, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+
+	response, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+
+	// Then
+	assert.Equal(t, http.StatusEarlyHints, interim.StatusCode)
+	assert.Equal(t, "</style.css>; rel=preload", interim.Header.Get("Link"))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "ok", string(body))
+}
+
+// forwardHook sends requests to the server as is
+func forwardHook(request *http.Request, roundTrip RoundTrip) (*http.Response, func(error), error) {
+	response, err := roundTrip(request)
+	return response, nil, err
+}
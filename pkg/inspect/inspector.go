@@ -0,0 +1,204 @@
+package inspect
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/eko/monday/pkg/ui"
+)
+
+const (
+	// MaxExchanges is the number of exchanges kept by the inspector, the oldest ones are dropped first
+	MaxExchanges = 1000
+
+	// MaxBodySize is the maximum size of the request and response bodies kept for each exchange
+	MaxBodySize = 64 * 1024
+)
+
+// Exchange represents a captured HTTP request and its response
+type Exchange struct {
+	ID               int           `json:"id"`
+	ReplayOf         int           `json:"replay_of,omitempty"`
+	Forward          string        `json:"forward"`
+	Target           string        `json:"target"`
+	Scheme           string        `json:"scheme"`
+	StartedAt        time.Time     `json:"started_at"`
+	Duration         time.Duration `json:"duration"`
+	Method           string        `json:"method"`
+	Host             string        `json:"host"`
+	Path             string        `json:"path"`
+	Proto            string        `json:"proto"`
+	RequestHeaders   http.Header   `json:"request_headers"`
+	RequestBody      []byte        `json:"request_body"`
+	RequestBodySize  int64         `json:"request_body_size"`
+	Status           int           `json:"status"`
+	ResponseHeaders  http.Header   `json:"response_headers"`
+	ResponseBody     []byte        `json:"response_body"`
+	ResponseBodySize int64         `json:"response_body_size"`
+	Error            string        `json:"error,omitempty"`
+}
+
+// IsRequestBodyTruncated indicates if the request body is larger than the captured one
+func (e *Exchange) IsRequestBodyTruncated() bool {
+	return int64(len(e.RequestBody)) < e.RequestBodySize
+}
+
+// Inspector captures the HTTP exchanges going through the proxy
+type Inspector struct {
+	view      ui.View
+	exchanges []*Exchange
+	lastID    int
+	mux       sync.RWMutex
+}
+
+// NewInspector returns a new HTTP inspector writing a line for each captured exchange into the given view
+func NewInspector(view ui.View) *Inspector {
+	return &Inspector{
+		view:      view,
+		exchanges: make([]*Exchange, 0),
+	}
+}
+
+// Hook returns the hook capturing the HTTP exchanges proxified by ProxyHTTP for the given forward.
+// Upgraded connections (like WebSockets) are not captured.
+func (i *Inspector) Hook(forward, target, scheme string) Hook {
+	return func(request *http.Request, roundTrip RoundTrip) (*http.Response, func(error), error) {
+		exchange := &Exchange{
+			Forward:        forward,
+			Target:         target,
+			Scheme:         scheme,
+			StartedAt:      time.Now(),
+			Method:         request.Method,
+			Host:           request.Host,
+			Path:           request.RequestURI,
+			Proto:          request.Proto,
+			RequestHeaders: request.Header.Clone(),
+		}
+
+		requestBody := newLimitedBuffer(MaxBodySize)
+		request.Body = teeReadCloser(request.Body, requestBody)
+
+		var responseBody *limitedBuffer
+
+		// Exchanges are recorded once responses have been entirely written to the client
+		done := func(err error) {
+			if err != nil {
+				exchange.Error = err.Error()
+			}
+
+			i.record(exchange, requestBody, responseBody)
+		}
+
+		response, err := roundTrip(request)
+		if err != nil {
+			return nil, done, err
+		}
+
+		exchange.Status = response.StatusCode
+		exchange.ResponseHeaders = response.Header.Clone()
+
+		responseBody = newLimitedBuffer(MaxBodySize)
+		response.Body = teeReadCloser(response.Body, responseBody)
+
+		return response, done, nil
+	}
+}
+
+// record stores the given exchange, dropping the oldest one when the maximum is reached
+func (i *Inspector) record(exchange *Exchange, requestBody, responseBody *limitedBuffer) {
+	exchange.Duration = time.Since(exchange.StartedAt)
+
+	if requestBody != nil {
+		exchange.RequestBody = requestBody.Bytes()
+		exchange.RequestBodySize = requestBody.size
+	}
+
+	if responseBody != nil {
+		exchange.ResponseBody = responseBody.Bytes()
+		exchange.ResponseBodySize = responseBody.size
+	}
+
+	i.mux.Lock()
+	i.lastID++
+	exchange.ID = i.lastID
+
+	i.exchanges = append(i.exchanges, exchange)
+	if len(i.exchanges) > MaxExchanges {
+		i.exchanges = i.exchanges[len(i.exchanges)-MaxExchanges:]
+	}
+	i.mux.Unlock()
+
+	if exchange.Error != "" {
+		i.view.Writef("🔎  #%d %s %s %s%s ❌ %s (%s)\n", exchange.ID, exchange.Forward, exchange.Method, exchange.Host, exchange.Path, exchange.Error, exchange.Duration.Round(time.Millisecond))
+		return
+	}
+
+	i.view.Writef("🔎  #%d %s %s %s%s → %d (%s)\n", exchange.ID, exchange.Forward, exchange.Method, exchange.Host, exchange.Path, exchange.Status, exchange.Duration.Round(time.Millisecond))
+}
+
+// GetExchanges returns the captured exchanges, from the oldest to the latest
+func (i *Inspector) GetExchanges() []*Exchange {
+	i.mux.RLock()
+	defer i.mux.RUnlock()
+
+	exchanges := make([]*Exchange, len(i.exchanges))
+	copy(exchanges, i.exchanges)
+
+	return exchanges
+}
+
+// GetExchange returns the captured exchange of the given identifier
+func (i *Inspector) GetExchange(id int) (*Exchange, bool) {
+	i.mux.RLock()
+	defer i.mux.RUnlock()
+
+	for _, exchange := range i.exchanges {
+		if exchange.ID == id {
+			return exchange, true
+		}
+	}
+
+	return nil, false
+}
+
+// limitedBuffer keeps the first bytes written into it, up to its maximum size, and counts all of them
+type limitedBuffer struct {
+	bytes.Buffer
+	max  int
+	size int64
+}
+
+func newLimitedBuffer(max int) *limitedBuffer {
+	return &limitedBuffer{max: max}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.size += int64(len(p))
+
+	if remaining := b.max - b.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.Buffer.Write(p[:remaining])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// teeReadCloser returns a body writing what is read from the given one into the given writer
+func teeReadCloser(body io.ReadCloser, writer io.Writer) io.ReadCloser {
+	if body == nil || body == http.NoBody {
+		return body
+	}
+
+	return readCloser{Reader: io.TeeReader(body, writer), Closer: body}
+}
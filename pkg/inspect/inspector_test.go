@@ -0,0 +1,320 @@
+package inspect
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInspect(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🔎  #%d %s %s %s%s → %d (%s)\n", 1, "graphql", "POST", "graphql.svc.local", "/query?debug=1", 201, gomock.Any())
+	view.EXPECT().Writef("🔎  #%d %s %s %s%s → %d (%s)\n", 2, "graphql", "GET", "graphql.svc.local", "/health", 200, gomock.Any())
+
+	inspector := NewInspector(view)
+
+	client, proxyClient := net.Pipe()
+	proxyServer, server := net.Pipe()
+	defer client.Close()
+
+	go serveHTTP(server)
+
+	// When
+	go ProxyHTTP(proxyClient, proxyServer, inspector.Hook("graphql", "127.0.0.1:9400", "http"))
+
+	reader := bufio.NewReader(client)
+
+	fmt.Fprint(client, "POST /query?debug=1 HTTP/1.1\r\nHost: graphql.svc.local\r\nContent-Type: application/json\r\nContent-Length: 17\r\n\r\n{\"query\":\"{ id }\"")
+	firstResponse, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+	firstBody, _ := io.ReadAll(firstResponse.Body)
+
+	fmt.Fprint(client, "GET /health HTTP/1.1\r\nHost: graphql.svc.local\r\n\r\n")
+	secondResponse, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+	secondBody, _ := io.ReadAll(secondResponse.Body)
+
+	// Then
+	assert.Equal(t, http.StatusCreated, firstResponse.StatusCode)
+	assert.Equal(t, "POST /query?debug=1 {\"query\":\"{ id }\"", string(firstBody))
+	assert.Equal(t, http.StatusOK, secondResponse.StatusCode)
+	assert.Equal(t, "GET /health ", string(secondBody))
+
+	// Exchanges are recorded once responses have been entirely written to the client
+	assert.Eventually(t, func() bool { return len(inspector.GetExchanges()) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	exchanges := inspector.GetExchanges()
+
+	assert.Equal(t, 1, exchanges[0].ID)
+	assert.Equal(t, "graphql", exchanges[0].Forward)
+	assert.Equal(t, "127.0.0.1:9400", exchanges[0].Target)
+	assert.Equal(t, "POST", exchanges[0].Method)
+	assert.Equal(t, "/query?debug=1", exchanges[0].Path)
+	assert.Equal(t, "application/json", exchanges[0].RequestHeaders.Get("Content-Type"))
+	assert.Equal(t, "{\"query\":\"{ id }\"", string(exchanges[0].RequestBody))
+	assert.Equal(t, http.StatusCreated, exchanges[0].Status)
+	assert.Equal(t, "POST /query?debug=1 {\"query\":\"{ id }\"", string(exchanges[0].ResponseBody))
+	assert.Equal(t, "text/plain", exchanges[0].ResponseHeaders.Get("Content-Type"))
+
+	assert.Equal(t, 2, exchanges[1].ID)
+	assert.Equal(t, "/health", exchanges[1].Path)
+	assert.Equal(t, http.StatusOK, exchanges[1].Status)
+}
+
+func TestInspectWhenBodyIsLargerThanMaximum(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef(gomock.Any(), gomock.Any()).AnyTimes()
+
+	inspector := NewInspector(view)
+
+	client, proxyClient := net.Pipe()
+	proxyServer, server := net.Pipe()
+	defer client.Close()
+
+	go serveHTTP(server)
+
+	body := strings.Repeat("a", MaxBodySize+10)
+
+	// When
+	go ProxyHTTP(proxyClient, proxyServer, inspector.Hook("graphql", "127.0.0.1:9400", "http"))
+
+	go fmt.Fprintf(client, "POST /upload HTTP/1.1\r\nHost: graphql.svc.local\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+
+	response, err := http.ReadResponse(bufio.NewReader(client), nil)
+	assert.Nil(t, err)
+	responseBody, _ := io.ReadAll(response.Body)
+
+	// Then
+	assert.Equal(t, "POST /upload "+body, string(responseBody))
+
+	assert.Eventually(t, func() bool { return len(inspector.GetExchanges()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	exchange, ok := inspector.GetExchange(1)
+	assert.True(t, ok)
+	assert.Len(t, exchange.RequestBody, MaxBodySize)
+	assert.Equal(t, int64(len(body)), exchange.RequestBodySize)
+	assert.True(t, exchange.IsRequestBodyTruncated())
+
+	_, err = inspector.Replay(context.Background(), 1)
+	assert.EqualError(t, err, fmt.Sprintf("Request body of #1 is larger than %d bytes and has been truncated, it cannot be replayed", MaxBodySize))
+}
+
+func TestInspectorHookWhenChained(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🔎  #%d %s %s %s%s → %d (%s)\n", 1, "graphql", "GET", "graphql.svc.local", "/unavailable", 503, gomock.Any())
+	view.EXPECT().Writef("🔎  #%d %s %s %s%s → %d (%s)\n", 2, "graphql", "GET", "graphql.svc.local", "/health", 200, gomock.Any())
+
+	inspector := NewInspector(view)
+
+	// Requests to /unavailable are answered without being sent to the server
+	unavailable := func(request *http.Request, roundTrip RoundTrip) (*http.Response, func(error), error) {
+		if request.URL.Path != "/unavailable" {
+			response, err := roundTrip(request)
+			return response, nil, err
+		}
+
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, ProtoMajor: 1, ProtoMinor: 1, Body: http.NoBody}, nil, nil
+	}
+
+	client, proxyClient := net.Pipe()
+	proxyServer, server := net.Pipe()
+	defer client.Close()
+
+	go serveHTTP(server)
+
+	// When
+	go ProxyHTTP(proxyClient, proxyServer, ChainHooks(inspector.Hook("graphql", "127.0.0.1:9400", "http"), unavailable))
+
+	reader := bufio.NewReader(client)
+
+	fmt.Fprint(client, "GET /unavailable HTTP/1.1\r\nHost: graphql.svc.local\r\n\r\n")
+	firstResponse, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+	io.ReadAll(firstResponse.Body)
+
+	fmt.Fprint(client, "GET /health HTTP/1.1\r\nHost: graphql.svc.local\r\n\r\n")
+	secondResponse, err := http.ReadResponse(reader, nil)
+	assert.Nil(t, err)
+	secondBody, _ := io.ReadAll(secondResponse.Body)
+
+	// Then
+	assert.Equal(t, http.StatusServiceUnavailable, firstResponse.StatusCode)
+	assert.Equal(t, http.StatusOK, secondResponse.StatusCode)
+	assert.Equal(t, "GET /health ", string(secondBody))
+
+	assert.Eventually(t, func() bool { return len(inspector.GetExchanges()) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	exchanges := inspector.GetExchanges()
+	assert.Equal(t, http.StatusServiceUnavailable, exchanges[0].Status)
+	assert.Equal(t, http.StatusOK, exchanges[1].Status)
+}
+
+func TestRecordWhenMaximumIsReached(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef(gomock.Any(), gomock.Any()).AnyTimes()
+
+	inspector := NewInspector(view)
+
+	// When
+	for i := 0; i < MaxExchanges+5; i++ {
+		inspector.record(&Exchange{Method: "GET", Path: "/"}, nil, nil)
+	}
+
+	// Then
+	exchanges := inspector.GetExchanges()
+	assert.Len(t, exchanges, MaxExchanges)
+	assert.Equal(t, 6, exchanges[0].ID)
+
+	_, ok := inspector.GetExchange(5)
+	assert.False(t, ok)
+}
+
+func TestReplay(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🔎  #%d %s %s %s%s → %d (%s)\n", 2, "graphql", "POST", "graphql.svc.local", "/query", 201, gomock.Any())
+
+	var receivedHost, receivedHeader string
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receivedHost = r.Host
+		receivedHeader = r.Header.Get("X-Request-Id")
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.RequestURI(), body)
+	}))
+	defer target.Close()
+
+	inspector := NewInspector(view)
+	inspector.exchanges = append(inspector.exchanges, &Exchange{
+		ID:              1,
+		Forward:         "graphql",
+		Target:          strings.TrimPrefix(target.URL, "http://"),
+		Method:          "POST",
+		Host:            "graphql.svc.local",
+		Path:            "/query",
+		Proto:           "HTTP/1.1",
+		RequestHeaders:  http.Header{"X-Request-Id": []string{"abc"}},
+		RequestBody:     []byte("{}"),
+		RequestBodySize: 2,
+	})
+	inspector.lastID = 1
+
+	// When
+	exchange, err := inspector.Replay(context.Background(), 1)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, 2, exchange.ID)
+	assert.Equal(t, 1, exchange.ReplayOf)
+	assert.Equal(t, http.StatusCreated, exchange.Status)
+	assert.Equal(t, "POST /query {}", string(exchange.ResponseBody))
+
+	assert.Equal(t, "graphql.svc.local", receivedHost)
+	assert.Equal(t, "abc", receivedHeader)
+
+	assert.Len(t, inspector.GetExchanges(), 2)
+}
+
+func TestReplayWhenExchangeDoesNotExist(t *testing.T) {
+	// Given
+	inspector := NewInspector(nil)
+
+	// When
+	exchange, err := inspector.Replay(context.Background(), 42)
+
+	// Then
+	assert.Nil(t, exchange)
+	assert.EqualError(t, err, "Request #42 has not been captured (or is not kept anymore)")
+}
+
+func TestNewHAR(t *testing.T) {
+	// Given
+	exchanges := []*Exchange{{
+		ID:               1,
+		Scheme:           "https",
+		Method:           "POST",
+		Host:             "graphql.svc.local",
+		Path:             "/query?debug=1&a=b",
+		Proto:            "HTTP/1.1",
+		RequestHeaders:   http.Header{"Content-Type": []string{"application/json"}, "Accept": []string{"*/*"}},
+		RequestBody:      []byte("{}"),
+		RequestBodySize:  2,
+		Status:           http.StatusOK,
+		ResponseHeaders:  http.Header{"Content-Type": []string{"application/octet-stream"}},
+		ResponseBody:     []byte{0xff, 0xfe},
+		ResponseBodySize: 2,
+	}}
+
+	// When
+	har := NewHAR(exchanges, "1.0.0")
+
+	// Then
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Equal(t, HARCreator{Name: "Monday", Version: "1.0.0"}, har.Log.Creator)
+	assert.Len(t, har.Log.Entries, 1)
+
+	entry := har.Log.Entries[0]
+	assert.Equal(t, "https://graphql.svc.local/query?debug=1&a=b", entry.Request.URL)
+	assert.Equal(t, []HARNameValue{{Name: "Accept", Value: "*/*"}, {Name: "Content-Type", Value: "application/json"}}, entry.Request.Headers)
+	assert.Equal(t, []HARNameValue{{Name: "a", Value: "b"}, {Name: "debug", Value: "1"}}, entry.Request.QueryString)
+	assert.Equal(t, &HARPostData{MimeType: "application/json", Text: "{}"}, entry.Request.PostData)
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Equal(t, "OK", entry.Response.StatusText)
+	assert.Equal(t, HARContent{Size: 2, MimeType: "application/octet-stream", Text: "//4=", Encoding: "base64"}, entry.Response.Content)
+}
+
+// serveHTTP answers the requests of the given connection with their method, URI and body
+func serveHTTP(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		request, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+
+		body, _ := io.ReadAll(request.Body)
+		content := fmt.Sprintf("%s %s %s", request.Method, request.RequestURI, body)
+
+		status := http.StatusOK
+		if request.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+
+		fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Type: text/plain\r\nContent-Length: %d\r\n\r\n%s", status, http.StatusText(status), len(content), content)
+	}
+}
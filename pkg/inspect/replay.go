@@ -0,0 +1,98 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// replayTimeout is the time given to the target to answer a replayed request
+var replayTimeout = 30 * time.Second
+
+// URL returns the URL requested by the client of this exchange
+func (e *Exchange) URL() string {
+	scheme := e.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, e.Host, e.Path)
+}
+
+// Replay sends again the request of the given captured exchange to its target and returns the new
+// exchange, which is captured as well
+func (i *Inspector) Replay(ctx context.Context, id int) (*Exchange, error) {
+	original, ok := i.GetExchange(id)
+	if !ok {
+		return nil, fmt.Errorf("Request #%d has not been captured (or is not kept anymore)", id)
+	}
+
+	if original.IsRequestBodyTruncated() {
+		return nil, fmt.Errorf("Request body of #%d is larger than %d bytes and has been truncated, it cannot be replayed", id, MaxBodySize)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+
+	// Plaintext is sent to the target, even if TLS was terminated by the proxy
+	url := fmt.Sprintf("http://%s%s", original.Target, original.Path)
+
+	request, err := http.NewRequestWithContext(ctx, original.Method, url, bytes.NewReader(original.RequestBody))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create replayed request of #%d: %v", id, err)
+	}
+
+	request.Host = original.Host
+	request.Header = original.RequestHeaders.Clone()
+	request.ContentLength = int64(len(original.RequestBody))
+
+	exchange := &Exchange{
+		ReplayOf:       original.ID,
+		Forward:        original.Forward,
+		Target:         original.Target,
+		Scheme:         original.Scheme,
+		StartedAt:      time.Now(),
+		Method:         original.Method,
+		Host:           original.Host,
+		Path:           original.Path,
+		Proto:          original.Proto,
+		RequestHeaders: original.RequestHeaders.Clone(),
+	}
+
+	requestBody := newLimitedBuffer(MaxBodySize)
+	requestBody.Write(original.RequestBody)
+
+	client := &http.Client{
+		// Redirections are returned as is, like they were to the original client
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			// Bodies are captured as sent by the target
+			DisableCompression: true,
+		},
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		exchange.Error = err.Error()
+		i.record(exchange, requestBody, nil)
+		return exchange, nil
+	}
+	defer response.Body.Close()
+
+	exchange.Status = response.StatusCode
+	exchange.ResponseHeaders = response.Header.Clone()
+
+	responseBody := newLimitedBuffer(MaxBodySize)
+	if _, err := io.Copy(responseBody, response.Body); err != nil {
+		exchange.Error = err.Error()
+	}
+
+	i.record(exchange, requestBody, responseBody)
+
+	return exchange, nil
+}
@@ -0,0 +1,118 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/eko/monday/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+var (
+	defaultKubeConfigPath = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+	// envClientSet returns the client set and namespace used to read the given ConfigMap or Secret
+	envClientSet = newClientSet
+)
+
+// newClientSet returns a client set of the context of the given source (the current one if empty), along
+// with the namespace of the source (the one of the context if empty)
+func newClientSet(source *config.EnvFrom) (kubernetes.Interface, string, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: getKubeConfigPath()},
+		&clientcmd.ConfigOverrides{CurrentContext: source.Context, Context: api.Context{Namespace: source.Namespace}},
+	)
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return clientSet, namespace, nil
+}
+
+func getKubeConfigPath() string {
+	if value := os.Getenv("MONDAY_KUBE_CONFIG"); value != "" {
+		return value
+	}
+
+	return defaultKubeConfigPath
+}
+
+// GetEnvVariables returns the environment variables read from the given ConfigMaps and Secrets. When
+// several of them define the same variable, the last one wins.
+func GetEnvVariables(ctx context.Context, envFrom []*config.EnvFrom) (map[string]string, error) {
+	envs := make(map[string]string)
+
+	for _, source := range envFrom {
+		clientSet, namespace, err := envClientSet(source)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := getEnvData(ctx, clientSet, namespace, source)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(source.Keys) == 0 {
+			for key, value := range data {
+				envs[key] = value
+			}
+			continue
+		}
+
+		for _, key := range source.Keys {
+			value, ok := data[key]
+			if !ok {
+				return nil, fmt.Errorf("Key '%s' does not exist in %s '%s' of namespace '%s'", key, source.Kind, source.Name, namespace)
+			}
+
+			envs[key] = value
+		}
+	}
+
+	return envs, nil
+}
+
+func getEnvData(ctx context.Context, clientSet kubernetes.Interface, namespace string, source *config.EnvFrom) (map[string]string, error) {
+	switch source.Kind {
+	case config.EnvFromConfigMap:
+		configMap, err := clientSet.CoreV1().ConfigMaps(namespace).Get(ctx, source.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read configmap '%s' of namespace '%s': %w", source.Name, namespace, err)
+		}
+
+		return configMap.Data, nil
+
+	case config.EnvFromSecret:
+		secret, err := clientSet.CoreV1().Secrets(namespace).Get(ctx, source.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read secret '%s' of namespace '%s': %w", source.Name, namespace, err)
+		}
+
+		data := make(map[string]string, len(secret.Data))
+		for key, value := range secret.Data {
+			data[key] = string(value)
+		}
+
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("Unsupported env_from kind '%s', please use '%s' or '%s'", source.Kind, config.EnvFromConfigMap, config.EnvFromSecret)
+}
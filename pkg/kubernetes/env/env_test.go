@@ -0,0 +1,93 @@
+package env
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetEnvVariables(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	useEnvClientSet(t, fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "graphql", Namespace: "backend"},
+			Data:       map[string]string{"HTTP_PORT": "8080", "LOG_LEVEL": "info"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "graphql", Namespace: "backend"},
+			Data:       map[string][]byte{"DATABASE_URL": []byte("postgres://preprod"), "API_KEY": []byte("secret")},
+		},
+	))
+
+	envFrom := []*config.EnvFrom{
+		{Kind: config.EnvFromConfigMap, Namespace: "backend", Name: "graphql"},
+		{Kind: config.EnvFromSecret, Namespace: "backend", Name: "graphql", Keys: []string{"DATABASE_URL"}},
+	}
+
+	// When
+	envs, err := GetEnvVariables(ctx, envFrom)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"HTTP_PORT":    "8080",
+		"LOG_LEVEL":    "info",
+		"DATABASE_URL": "postgres://preprod",
+	}, envs)
+}
+
+func TestGetEnvVariablesWhenKeyDoesNotExist(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	useEnvClientSet(t, fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "graphql", Namespace: "backend"},
+		Data:       map[string]string{"HTTP_PORT": "8080"},
+	}))
+
+	envFrom := []*config.EnvFrom{
+		{Kind: config.EnvFromConfigMap, Namespace: "backend", Name: "graphql", Keys: []string{"GRPC_PORT"}},
+	}
+
+	// When
+	envs, err := GetEnvVariables(ctx, envFrom)
+
+	// Then
+	assert.Nil(t, envs)
+	assert.EqualError(t, err, "Key 'GRPC_PORT' does not exist in configmap 'graphql' of namespace 'backend'")
+}
+
+func TestGetEnvVariablesWhenSecretDoesNotExist(t *testing.T) {
+	// Given
+	ctx := context.Background()
+
+	useEnvClientSet(t, fake.NewSimpleClientset())
+
+	envFrom := []*config.EnvFrom{
+		{Kind: config.EnvFromSecret, Namespace: "backend", Name: "graphql"},
+	}
+
+	// When
+	envs, err := GetEnvVariables(ctx, envFrom)
+
+	// Then
+	assert.Nil(t, envs)
+	assert.EqualError(t, err, "Unable to read secret 'graphql' of namespace 'backend': secrets \"graphql\" not found")
+}
+
+func useEnvClientSet(t *testing.T, clientSet *fake.Clientset) {
+	original := envClientSet
+	t.Cleanup(func() { envClientSet = original })
+
+	envClientSet = func(source *config.EnvFrom) (kubernetes.Interface, string, error) {
+		return clientSet, source.Namespace, nil
+	}
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"sync"
+)
+
+const (
+	// DefaultHubSize is the number of lines kept in memory for each stream name
+	DefaultHubSize = 1000
+)
+
+var (
+	// Logs is the hub receiving all the lines written by streamers so they can be
+	// retrieved later by stream name (local application or pod names)
+	Logs = NewHub(DefaultHubSize)
+)
+
+// Hub keeps the latest lines written for each stream name and notifies subscribers of new ones
+type Hub struct {
+	size        int
+	lines       map[string][]string
+	subscribers map[string]map[chan string]struct{}
+	mux         sync.RWMutex
+}
+
+// NewHub returns a new hub keeping at most the given number of lines per stream name
+func NewHub(size int) *Hub {
+	return &Hub{
+		size:        size,
+		lines:       make(map[string][]string),
+		subscribers: make(map[string]map[chan string]struct{}),
+	}
+}
+
+// Publish adds a new line for the given stream name
+func (h *Hub) Publish(name, line string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	lines := append(h.lines[name], line)
+	if len(lines) > h.size {
+		lines = lines[len(lines)-h.size:]
+	}
+	h.lines[name] = lines
+
+	for subscriber := range h.subscribers[name] {
+		// Do not block writers on slow subscribers
+		select {
+		case subscriber <- line:
+		default:
+		}
+	}
+}
+
+// Lines returns a copy of the latest lines kept for the given stream name
+func (h *Hub) Lines(name string) []string {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	return append([]string{}, h.lines[name]...)
+}
+
+// Names returns the stream names that have published at least one line
+func (h *Hub) Names() []string {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	names := make([]string, 0, len(h.lines))
+	for name := range h.lines {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// Subscribe returns the latest lines kept for the given stream name along with a channel receiving
+// the new ones. The returned function has to be called to unsubscribe.
+func (h *Hub) Subscribe(name string) ([]string, <-chan string, func()) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	channel := make(chan string, 100)
+
+	if _, ok := h.subscribers[name]; !ok {
+		h.subscribers[name] = make(map[chan string]struct{})
+	}
+	h.subscribers[name][channel] = struct{}{}
+
+	unsubscribe := func() {
+		h.mux.Lock()
+		defer h.mux.Unlock()
+
+		delete(h.subscribers[name], channel)
+	}
+
+	return append([]string{}, h.lines[name]...), channel, unsubscribe
+}
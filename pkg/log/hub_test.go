@@ -0,0 +1,40 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubPublish(t *testing.T) {
+	// Given
+	hub := NewHub(2)
+
+	// When
+	hub.Publish("test-app", "line 1\n")
+	hub.Publish("test-app", "line 2\n")
+	hub.Publish("test-app", "line 3\n")
+	hub.Publish("other-app", "other line\n")
+
+	// Then
+	assert.Equal(t, []string{"line 2\n", "line 3\n"}, hub.Lines("test-app"))
+	assert.Equal(t, []string{"other line\n"}, hub.Lines("other-app"))
+	assert.ElementsMatch(t, []string{"test-app", "other-app"}, hub.Names())
+}
+
+func TestHubSubscribe(t *testing.T) {
+	// Given
+	hub := NewHub(10)
+	hub.Publish("test-app", "line 1\n")
+
+	// When
+	lines, channel, unsubscribe := hub.Subscribe("test-app")
+	hub.Publish("test-app", "line 2\n")
+	unsubscribe()
+	hub.Publish("test-app", "line 3\n")
+
+	// Then
+	assert.Equal(t, []string{"line 1\n"}, lines)
+	assert.Equal(t, "line 2\n", <-channel)
+	assert.Len(t, channel, 0)
+}
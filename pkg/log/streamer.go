@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"sync"
 
 	"github.com/eko/monday/pkg/ui"
 )
@@ -29,6 +30,9 @@ type Streamer struct {
 	stdType string
 	name    string
 
+	listeners    []func(line string)
+	listenersMux sync.RWMutex
+
 	view ui.View
 }
 
@@ -49,6 +53,14 @@ func NewStreamer(stdType string, name string, view ui.View) *Streamer {
 	return streamer
 }
 
+// AddListener registers a function that will be called with each line written on the stream
+func (l *Streamer) AddListener(listener func(line string)) {
+	l.listenersMux.Lock()
+	defer l.listenersMux.Unlock()
+
+	l.listeners = append(l.listeners, listener)
+}
+
 func (l *Streamer) Write(p []byte) (n int, err error) {
 	if n, err = l.buf.Write(p); err != nil {
 		return
@@ -71,13 +83,15 @@ func (l *Streamer) Flush() error {
 		return nil
 	}
 
-	var p []byte
-	if _, err := l.buf.Read(p); err != nil {
-		return err
+	// Remaining data is a last line which does not end with a line break
+	if l.buf.Len() == 0 {
+		return nil
 	}
 
-	l.out(string(p))
-	return nil
+	line := l.buf.String()
+	l.buf.Reset()
+
+	return l.out(line + "\n")
 }
 
 func (l *Streamer) output() (err error) {
@@ -88,6 +102,8 @@ func (l *Streamer) output() (err error) {
 
 		line, err := l.buf.ReadString('\n')
 		if err == io.EOF {
+			// Keep the incomplete line until its end is written
+			l.buf.WriteString(line)
 			break
 		}
 		if err != nil {
@@ -101,6 +117,14 @@ func (l *Streamer) output() (err error) {
 }
 
 func (l *Streamer) out(str string) (err error) {
+	l.listenersMux.RLock()
+	for _, listener := range l.listeners {
+		listener(str)
+	}
+	l.listenersMux.RUnlock()
+
+	Logs.Publish(l.name, str)
+
 	switch l.stdType {
 	case StdOut:
 		str = ColorOkay + l.name + ColorReset + " " + str
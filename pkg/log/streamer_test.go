@@ -34,3 +34,49 @@ func TestNewStreamer(t *testing.T) {
 		assert.Equal(t, testCase.name, streamer.name)
 	}
 }
+
+func TestStreamerListener(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Write(gomock.Any()).Times(2)
+
+	streamer := NewStreamer(StdOut, "test-stdout", view)
+
+	lines := make([]string, 0)
+	streamer.AddListener(func(line string) {
+		lines = append(lines, line)
+	})
+
+	// When
+	streamer.Write([]byte("first line\nsecond line\nincomplete"))
+
+	// Then
+	assert.Equal(t, []string{"first line\n", "second line\n"}, lines)
+}
+
+func TestStreamerWhenLineIsWrittenInSeveralParts(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Write(gomock.Any()).Times(2)
+
+	streamer := NewStreamer(StdOut, "test-stdout", view)
+
+	lines := make([]string, 0)
+	streamer.AddListener(func(line string) {
+		lines = append(lines, line)
+	})
+
+	// When
+	streamer.Write([]byte("first "))
+	streamer.Write([]byte("line\nlast"))
+	streamer.Close()
+
+	// Then
+	assert.Equal(t, []string{"first line\n", "last\n"}, lines)
+}
@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+)
+
+const (
+	// TLSPort is the local port HTTPS applications are served on
+	TLSPort = "443"
+
+	caCertificateFile = "ca.crt"
+	caKeyFile         = "ca.key"
+
+	caValidity = 10 * 365 * 24 * time.Hour
+
+	// certificateValidity is the maximum validity accepted by browsers for server certificates
+	certificateValidity = 397 * 24 * time.Hour
+)
+
+// CertificateAuthority issues the certificates of the hostnames served over TLS by the proxy
+type CertificateAuthority struct {
+	certificate  *x509.Certificate
+	key          *ecdsa.PrivateKey
+	certificates map[string]*tls.Certificate
+	mux          sync.Mutex
+}
+
+// GetCAPath returns the directory of the local certificate authority
+func GetCAPath() string {
+	return filepath.Join(config.GetDataPath(), "ca")
+}
+
+// LoadCertificateAuthority loads the certificate authority stored in the given directory, creating it
+// the first time. The returned boolean indicates whether it has been created.
+func LoadCertificateAuthority(path string) (*CertificateAuthority, bool, error) {
+	certificatePath := filepath.Join(path, caCertificateFile)
+	keyPath := filepath.Join(path, caKeyFile)
+
+	certificatePEM, err := os.ReadFile(certificatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		ca, err := createCertificateAuthority(path)
+		return ca, true, err
+	} else if err != nil {
+		return nil, false, fmt.Errorf("unable to read certificate authority '%s': %v", certificatePath, err)
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read certificate authority key '%s': %v", keyPath, err)
+	}
+
+	certificateBlock, _ := pem.Decode(certificatePEM)
+	if certificateBlock == nil {
+		return nil, false, fmt.Errorf("no certificate found in '%s'", certificatePath)
+	}
+
+	certificate, err := x509.ParseCertificate(certificateBlock.Bytes)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to parse certificate authority '%s': %v", certificatePath, err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, false, fmt.Errorf("no private key found in '%s'", keyPath)
+	}
+
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to parse certificate authority key '%s': %v", keyPath, err)
+	}
+
+	return newCertificateAuthority(certificate, key), false, nil
+}
+
+func createCertificateAuthority(path string) (*CertificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: "Monday local certificate authority", Organization: []string{"Monday"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create certificate authority directory '%s': %v", path, err)
+	}
+
+	keyPath := filepath.Join(path, caKeyFile)
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, fmt.Errorf("unable to write certificate authority key '%s': %v", keyPath, err)
+	}
+
+	certificatePath := filepath.Join(path, caCertificateFile)
+	if err := os.WriteFile(certificatePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return nil, fmt.Errorf("unable to write certificate authority '%s': %v", certificatePath, err)
+	}
+
+	return newCertificateAuthority(certificate, key), nil
+}
+
+func newCertificateAuthority(certificate *x509.Certificate, key *ecdsa.PrivateKey) *CertificateAuthority {
+	return &CertificateAuthority{
+		certificate:  certificate,
+		key:          key,
+		certificates: make(map[string]*tls.Certificate),
+	}
+}
+
+// GetCertificate returns a certificate of the given hostname signed by the certificate authority,
+// issuing it the first time
+func (ca *CertificateAuthority) GetCertificate(hostname string) (*tls.Certificate, error) {
+	ca.mux.Lock()
+	defer ca.mux.Unlock()
+
+	if certificate, ok := ca.certificates[hostname]; ok {
+		return certificate, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: hostname, Organization: []string{"Monday"}},
+		DNSNames:     []string{hostname},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to issue certificate of '%s': %v", hostname, err)
+	}
+
+	certificate := &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+
+	ca.certificates[hostname] = certificate
+
+	return certificate, nil
+}
+
+// TLSConfig returns the configuration used to terminate the TLS connections of the given hostname
+func (ca *CertificateAuthority) TLSConfig(hostname string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return ca.GetCertificate(hostname)
+		},
+	}
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCertificateAuthority(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "ca")
+
+	// When
+	created, isCreated, err := LoadCertificateAuthority(path)
+	loaded, isLoadedCreated, loadErr := LoadCertificateAuthority(path)
+
+	// Then
+	assert.Nil(t, err)
+	assert.True(t, isCreated)
+
+	assert.Nil(t, loadErr)
+	assert.False(t, isLoadedCreated)
+
+	assert.True(t, created.certificate.Equal(loaded.certificate))
+	assert.True(t, loaded.certificate.IsCA)
+
+	info, err := os.Stat(filepath.Join(path, caKeyFile))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestGetCertificate(t *testing.T) {
+	// Given
+	ca, _, err := LoadCertificateAuthority(t.TempDir())
+	assert.Nil(t, err)
+
+	// When
+	certificate, err := ca.GetCertificate("graphql.svc.local")
+
+	// Then
+	assert.Nil(t, err)
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Nil(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "graphql.svc.local", Roots: roots})
+	assert.Nil(t, err)
+
+	// Certificates are issued once per hostname
+	cached, err := ca.GetCertificate("graphql.svc.local")
+	assert.Nil(t, err)
+	assert.Same(t, certificate, cached)
+}
@@ -0,0 +1,335 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/inspect"
+)
+
+var (
+	// randomFloat returns the random number in [0.0, 1.0) deciding whether a fault is injected
+	randomFloat = rand.Float64
+
+	// resetReadTimeout is the time given to clients to send data before their connection is reset
+	resetReadTimeout = 10 * time.Second
+)
+
+// Faults represents the faults injected by the proxy into the connections of a forward
+type Faults struct {
+	Latency     time.Duration
+	Jitter      time.Duration
+	ResetRate   float64
+	Bandwidth   int64
+	ErrorRate   float64
+	ErrorStatus int
+}
+
+// NewFaults returns the faults to inject from the given configuration, nil if there is none
+func NewFaults(conf *config.Faults) *Faults {
+	if conf == nil {
+		return nil
+	}
+
+	return &Faults{
+		Latency:     conf.Latency,
+		Jitter:      conf.Jitter,
+		ResetRate:   conf.ResetRate,
+		Bandwidth:   conf.GetBandwidth(),
+		ErrorRate:   conf.ErrorRate,
+		ErrorStatus: conf.GetErrorStatus(),
+	}
+}
+
+// String returns a human readable summary of the injected faults
+func (f *Faults) String() string {
+	var faults []string
+
+	if f.Latency > 0 || f.Jitter > 0 {
+		latency := fmt.Sprintf("%s latency", f.Latency)
+		if f.Jitter > 0 {
+			latency = fmt.Sprintf("%s±%s latency", f.Latency, f.Jitter)
+		}
+
+		faults = append(faults, latency)
+	}
+
+	if f.ResetRate > 0 {
+		faults = append(faults, fmt.Sprintf("%s%% connection resets", formatPercent(f.ResetRate)))
+	}
+
+	if f.Bandwidth > 0 {
+		faults = append(faults, fmt.Sprintf("%dKB/s bandwidth", f.Bandwidth/1024))
+	}
+
+	if f.ErrorRate > 0 {
+		faults = append(faults, fmt.Sprintf("%s%% HTTP %d errors", formatPercent(f.ErrorRate), f.ErrorStatus))
+	}
+
+	if len(faults) == 0 {
+		return "none"
+	}
+
+	return strings.Join(faults, ", ")
+}
+
+func formatPercent(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', -1, 64)
+}
+
+// delay returns the latency to add to the data sent to the target
+func (f *Faults) delay() time.Duration {
+	delay := f.Latency
+
+	if f.Jitter > 0 {
+		delay += time.Duration((randomFloat()*2 - 1) * float64(f.Jitter))
+	}
+
+	if delay < 0 {
+		return 0
+	}
+
+	return delay
+}
+
+// ToggleFaults enables (or disables) the injection of faults in all the forwards and returns whether
+// they are now enabled. Established connections are affected as well.
+func (p *proxy) ToggleFaults() bool {
+	enabled := !p.areFaultsEnabled()
+	p.faultsDisabled.Store(!enabled)
+
+	if enabled {
+		p.view.Writef("💥  Faults injection has been enabled\n")
+	} else {
+		p.view.Writef("💤  Faults injection has been disabled\n")
+	}
+
+	return enabled
+}
+
+func (p *proxy) areFaultsEnabled() bool {
+	return !p.faultsDisabled.Load()
+}
+
+// injectFaults returns the target connection the latency and bandwidth faults of the given forward are
+// injected into, or false when the client connection has been reset
+func (p *proxy) injectFaults(pf *ProxyForward, client, target net.Conn) (net.Conn, bool) {
+	faults := pf.Faults
+
+	if p.areFaultsEnabled() && faults.ResetRate > 0 && randomFloat() < faults.ResetRate {
+		// Connection is reset once the client has sent its request
+		client.SetReadDeadline(time.Now().Add(resetReadTimeout))
+		client.Read(make([]byte, 1))
+
+		resetConnection(client)
+		return nil, false
+	}
+
+	if faults.Latency > 0 || faults.Jitter > 0 || faults.Bandwidth > 0 {
+		target = newFaultyConn(target, faults, p.areFaultsEnabled)
+	}
+
+	return target, true
+}
+
+// resetConnection closes the given connection, sending a TCP RST instead of a FIN
+func resetConnection(conn net.Conn) {
+	for {
+		switch c := conn.(type) {
+		case *tls.Conn:
+			conn = c.NetConn()
+			continue
+		case *replayConn:
+			conn = c.Conn
+			continue
+		case *net.TCPConn:
+			c.SetLinger(0)
+		}
+
+		conn.Close()
+		return
+	}
+}
+
+// faultyConn is a connection whose written data are delayed and whose bandwidth is limited
+type faultyConn struct {
+	net.Conn
+	faults    *Faults
+	enabled   func() bool
+	chunks    chan faultyChunk
+	done      chan struct{}
+	closeOnce sync.Once
+	lastAt    time.Time
+	reads     *bandwidthLimiter
+	writes    *bandwidthLimiter
+}
+
+type faultyChunk struct {
+	data []byte
+	at   time.Time
+}
+
+func newFaultyConn(conn net.Conn, faults *Faults, enabled func() bool) *faultyConn {
+	c := &faultyConn{
+		Conn:    conn,
+		faults:  faults,
+		enabled: enabled,
+		chunks:  make(chan faultyChunk, 64),
+		done:    make(chan struct{}),
+		reads:   newBandwidthLimiter(faults.Bandwidth, enabled),
+		writes:  newBandwidthLimiter(faults.Bandwidth, enabled),
+	}
+
+	go c.deliver()
+
+	return c
+}
+
+func (c *faultyConn) Read(p []byte) (int, error) {
+	if size := c.reads.chunkSize(); size > 0 && len(p) > size {
+		p = p[:size]
+	}
+
+	n, err := c.Conn.Read(p)
+	c.reads.wait(n)
+
+	return n, err
+}
+
+// Write queues the given data to be written once the latency has elapsed, keeping their order
+func (c *faultyConn) Write(p []byte) (int, error) {
+	at := time.Now()
+	if c.enabled() {
+		at = at.Add(c.faults.delay())
+	}
+
+	if at.Before(c.lastAt) {
+		at = c.lastAt
+	}
+	c.lastAt = at
+
+	select {
+	case c.chunks <- faultyChunk{data: append([]byte(nil), p...), at: at}:
+		return len(p), nil
+	case <-c.done:
+		return 0, net.ErrClosed
+	}
+}
+
+func (c *faultyConn) deliver() {
+	for {
+		select {
+		case chunk := <-c.chunks:
+			time.Sleep(time.Until(chunk.at))
+
+			for data := chunk.data; len(data) > 0; {
+				size := len(data)
+				if chunkSize := c.writes.chunkSize(); chunkSize > 0 && size > chunkSize {
+					size = chunkSize
+				}
+
+				n, err := c.Conn.Write(data[:size])
+				if err != nil {
+					c.Close()
+					return
+				}
+
+				c.writes.wait(n)
+				data = data[n:]
+			}
+
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *faultyConn) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+
+	return c.Conn.Close()
+}
+
+// bandwidthLimiter slows down a transfer so that it does not exceed its number of bytes per second
+type bandwidthLimiter struct {
+	bytesPerSecond int64
+	enabled        func() bool
+	start          time.Time
+	transferred    int64
+}
+
+func newBandwidthLimiter(bytesPerSecond int64, enabled func() bool) *bandwidthLimiter {
+	return &bandwidthLimiter{bytesPerSecond: bytesPerSecond, enabled: enabled, start: time.Now()}
+}
+
+// chunkSize returns the maximum size of data transferred at once, 0 if unlimited
+func (l *bandwidthLimiter) chunkSize() int {
+	if l.bytesPerSecond <= 0 || !l.enabled() {
+		return 0
+	}
+
+	// Transfers are split so that they are spread over the second
+	if size := int(l.bytesPerSecond / 10); size > 0 {
+		return size
+	}
+
+	return 1
+}
+
+// wait sleeps until the given number of transferred bytes fits in the bandwidth
+func (l *bandwidthLimiter) wait(n int) {
+	if l.bytesPerSecond <= 0 || !l.enabled() || n <= 0 {
+		return
+	}
+
+	elapsed := time.Since(l.start)
+	expected := time.Duration(float64(l.transferred) / float64(l.bytesPerSecond) * float64(time.Second))
+
+	// Idle time is not given as credit to the next transfers
+	if elapsed > expected+time.Second {
+		l.start = time.Now()
+		l.transferred = 0
+		elapsed = 0
+	}
+
+	l.transferred += int64(n)
+	expected = time.Duration(float64(l.transferred) / float64(l.bytesPerSecond) * float64(time.Second))
+
+	if sleep := expected - elapsed; sleep > 0 {
+		time.Sleep(sleep)
+	}
+}
+
+// injectHTTPErrors returns the hook answering a part of the HTTP requests with an error instead of
+// sending them to the target
+func injectHTTPErrors(faults *Faults, enabled func() bool) inspect.Hook {
+	return func(request *http.Request, roundTrip inspect.RoundTrip) (*http.Response, func(error), error) {
+		if !enabled() || randomFloat() >= faults.ErrorRate {
+			response, err := roundTrip(request)
+			return response, nil, err
+		}
+
+		io.Copy(io.Discard, request.Body)
+
+		body := fmt.Sprintf("Error injected by Monday proxy (%d %s)\n", faults.ErrorStatus, http.StatusText(faults.ErrorStatus))
+
+		return &http.Response{
+			StatusCode:    faults.ErrorStatus,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Close:         request.Close,
+		}, nil, nil
+	}
+}
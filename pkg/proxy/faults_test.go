@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/inspect"
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewFaults(t *testing.T) {
+	// When
+	faults := NewFaults(&config.Faults{
+		Latency:   200 * time.Millisecond,
+		Jitter:    50 * time.Millisecond,
+		ResetRate: 0.1,
+		Bandwidth: 256,
+		ErrorRate: 0.05,
+	})
+
+	// Then
+	assert.Equal(t, &Faults{
+		Latency:     200 * time.Millisecond,
+		Jitter:      50 * time.Millisecond,
+		ResetRate:   0.1,
+		Bandwidth:   256 * 1024,
+		ErrorRate:   0.05,
+		ErrorStatus: 503,
+	}, faults)
+
+	assert.Equal(t, "200ms±50ms latency, 10% connection resets, 256KB/s bandwidth, 5% HTTP 503 errors", faults.String())
+	assert.Nil(t, NewFaults(nil))
+}
+
+func TestInjectHTTPErrors(t *testing.T) {
+	// Given
+	randoms := []float64{0.1, 0.9}
+	mockRandomFloat(t, func() float64 {
+		random := randoms[0]
+		randoms = randoms[1:]
+		return random
+	})
+
+	conn, proxyClient := net.Pipe()
+	proxyTarget, target := net.Pipe()
+	defer conn.Close()
+
+	go serveStatus(target, http.StatusOK)
+
+	faults := &Faults{ErrorRate: 0.5, ErrorStatus: http.StatusBadGateway}
+
+	// When
+	go inspect.ProxyHTTP(proxyClient, proxyTarget, injectHTTPErrors(faults, func() bool { return true }))
+
+	reader := bufio.NewReader(conn)
+	first := sendRequest(t, conn, reader)
+	second := sendRequest(t, conn, reader)
+
+	// Then
+	assert.Equal(t, http.StatusBadGateway, first)
+	assert.Equal(t, http.StatusOK, second)
+}
+
+func TestInjectHTTPErrorsWhenDisabled(t *testing.T) {
+	// Given
+	mockRandomFloat(t, func() float64 { return 0 })
+
+	conn, proxyClient := net.Pipe()
+	proxyTarget, target := net.Pipe()
+	defer conn.Close()
+
+	go serveStatus(target, http.StatusOK)
+
+	faults := &Faults{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable}
+
+	// When
+	go inspect.ProxyHTTP(proxyClient, proxyTarget, injectHTTPErrors(faults, func() bool { return false }))
+
+	status := sendRequest(t, conn, bufio.NewReader(conn))
+
+	// Then
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestFaultyConnWhenLatency(t *testing.T) {
+	// Given
+	proxyTarget, target := net.Pipe()
+
+	conn := newFaultyConn(proxyTarget, &Faults{Latency: 200 * time.Millisecond}, func() bool { return true })
+	defer conn.Close()
+
+	// When
+	start := time.Now()
+
+	// Written data are queued, writes do not wait for the latency
+	conn.Write([]byte("first"))
+	conn.Write([]byte("second"))
+
+	buffer := make([]byte, 11)
+	_, err := io.ReadFull(target, buffer)
+
+	// Then
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, "firstsecond", string(buffer))
+}
+
+func TestBandwidthLimiter(t *testing.T) {
+	// Given
+	limiter := newBandwidthLimiter(10*1024, func() bool { return true })
+
+	// When
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.wait(limiter.chunkSize())
+	}
+
+	// Then
+	assert.Equal(t, 1024, limiter.chunkSize())
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+}
+
+func TestInjectFaultsWhenReset(t *testing.T) {
+	// Given
+	mockRandomFloat(t, func() float64 { return 0 })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	proxy := &proxy{}
+	pf := NewProxyForward("api", "api.svc.local", "", "8080", "8080")
+	pf.SetFaults(&Faults{ResetRate: 0.5})
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	server, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, target := net.Pipe()
+
+	// When
+	go client.Write([]byte("GET / HTTP/1.1\r\nHost: api.svc.local\r\n\r\n"))
+
+	_, ok := proxy.injectFaults(pf, server, target)
+
+	// Then
+	assert.False(t, ok)
+
+	_, err = client.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, syscall.ECONNRESET), "connection should have been reset: %v", err)
+}
+
+func TestToggleFaults(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("💤  Faults injection has been disabled\n")
+	view.EXPECT().Writef("💥  Faults injection has been enabled\n")
+
+	proxy := NewProxy(view, nil)
+
+	// When - Then
+	assert.True(t, proxy.areFaultsEnabled())
+
+	assert.False(t, proxy.ToggleFaults())
+	assert.False(t, proxy.areFaultsEnabled())
+
+	assert.True(t, proxy.ToggleFaults())
+	assert.True(t, proxy.areFaultsEnabled())
+}
+
+func mockRandomFloat(t *testing.T, random func() float64) {
+	original := randomFloat
+	randomFloat = random
+	t.Cleanup(func() { randomFloat = original })
+}
+
+// serveStatus answers the requests of the given connection with the given status
+func serveStatus(conn net.Conn, status int) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		if _, err := http.ReadRequest(reader); err != nil {
+			return
+		}
+
+		fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Length: 0\r\n\r\n", status, http.StatusText(status))
+	}
+}
+
+func sendRequest(t *testing.T, conn net.Conn, reader *bufio.Reader) int {
+	go conn.Write([]byte("GET / HTTP/1.1\r\nHost: api.svc.local\r\n\r\n"))
+
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+
+	return response.StatusCode
+}
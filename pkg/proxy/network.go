@@ -7,10 +7,15 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+
+	"github.com/eko/monday/pkg/state"
 )
 
 var (
 	networkInterface = ""
+
+	// lookPath checks if a command (like ifconfig or ip) is available
+	lookPath = exec.LookPath
 )
 
 func init() {
@@ -49,7 +54,7 @@ func getAddIPCommandWithArgs(ip string) (string, []string) {
 
 	case "linux":
 		// Check if "ifconfig" is available
-		_, err := exec.LookPath(command)
+		_, err := lookPath(command)
 		if err == nil {
 			// "ifconfig" case
 			args = []string{networkInterface, ip, "up"}
@@ -66,7 +71,67 @@ func getAddIPCommandWithArgs(ip string) (string, []string) {
 	return command, args
 }
 
-func assignIpToPort(a, b, c, d byte, port string) (byte, byte, byte, byte, error) {
+// getRemoveIPCommandWithArgs returns the command (ifconfig, ip, ...) that will be used for the current OS
+// to remove an IP address (given with the network prefix it has been assigned with) previously added on
+// the given network interface, and its associated arguments. The command is chosen the same way as the
+// one which added the address.
+func getRemoveIPCommandWithArgs(iface, cidr string) (string, []string) {
+	var command = "ifconfig"
+	var args []string
+
+	ip, _, _ := net.ParseCIDR(cidr)
+
+	switch runtime.GOOS {
+	case "darwin":
+		args = []string{iface, "-alias", ip.String()}
+
+	case "linux":
+		// Check if "ifconfig" is available
+		_, err := lookPath(command)
+		if err == nil {
+			// "ifconfig" case: IP has replaced the loopback address so put it back
+			args = []string{iface, "127.0.0.1", "up"}
+		} else {
+			// "ip" case
+			command = "ip"
+			args = []string{"addr", "del", cidr, "dev", iface}
+		}
+
+	default:
+		panic(fmt.Sprintf("Sorry, it seems your OS (%s) is not available yet.", runtime.GOOS))
+	}
+
+	return command, args
+}
+
+// RemoveIPAlias removes an IP address previously added on the given network interface.
+// It does nothing if the IP address is not assigned anymore.
+func RemoveIPAlias(iface, ip string) error {
+	netIface, err := net.InterfaceByName(iface)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := netIface.Addrs()
+	if err != nil {
+		return err
+	}
+
+	cidr, ok := getIPAddress(net.ParseIP(ip), addrs)
+	if !ok {
+		return nil
+	}
+
+	command, args := getRemoveIPCommandWithArgs(iface, cidr)
+
+	if err := exec.Command(command, args...).Run(); err != nil {
+		return fmt.Errorf("error while trying to run ifconfig/ip command to remove IP address (%s) from network interface '%s': %v", ip, iface, err)
+	}
+
+	return nil
+}
+
+func (p *proxy) assignIpToPort(a, b, c, d byte, port string) (byte, byte, byte, byte, error) {
 	// Retrieve network interface
 	iface, err := net.InterfaceByName(networkInterface)
 	if err != nil {
@@ -93,6 +158,12 @@ func assignIpToPort(a, b, c, d byte, port string) (byte, byte, byte, byte, error
 			if err := exec.Command(command, args...).Run(); err != nil {
 				return a, b, c, d, fmt.Errorf("error while trying to run ifconfig/ip command to add new IP address (%s) on network interface '%s': %v", ip.String(), networkInterface, err)
 			}
+
+			// Keep track of added IP addresses so they can be removed later
+			p.addedIPs = append(p.addedIPs, ip.String())
+			if err := state.Current.AddIPAlias(networkInterface, ip.String()); err != nil {
+				p.view.Writef("❌  Unable to record IP address '%s' in state file: %v\n", ip.String(), err)
+			}
 		}
 
 		// Can't be contacted on ip/port? it means this couple is free to be used
@@ -116,8 +187,19 @@ func isAlreadyAssigned(ip net.IP, addrs []net.Addr) bool {
 	return false
 }
 
+// getIPAddress returns the given IP address with the network prefix it is assigned with, if it is
+func getIPAddress(ip net.IP, addrs []net.Addr) (string, bool) {
+	for _, addr := range addrs {
+		if addrIP, _, err := net.ParseCIDR(addr.String()); err == nil && addrIP.Equal(ip) {
+			return addr.String(), true
+		}
+	}
+
+	return "", false
+}
+
 func canDial(ip, port string) bool {
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%s", ip, port))
+	conn, err := net.Dial("tcp", net.JoinHostPort(ip, port))
 	if conn != nil {
 		conn.Close()
 	}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAndRemoveIPCommandsWithArgs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ifconfig and ip commands are chosen on Linux only")
+	}
+
+	testCases := []struct {
+		name          string
+		commands      []string
+		addCommand    string
+		addArgs       []string
+		assignedCIDR  string
+		removeCommand string
+		removeArgs    []string
+	}{
+		{
+			name:          "ifconfig and ip",
+			commands:      []string{"ifconfig", "ip"},
+			addCommand:    "ifconfig",
+			addArgs:       []string{networkInterface, "127.0.1.1", "up"},
+			assignedCIDR:  "127.0.1.1/8",
+			removeCommand: "ifconfig",
+			removeArgs:    []string{networkInterface, "127.0.0.1", "up"},
+		},
+		{
+			name:          "ifconfig only",
+			commands:      []string{"ifconfig"},
+			addCommand:    "ifconfig",
+			addArgs:       []string{networkInterface, "127.0.1.1", "up"},
+			assignedCIDR:  "127.0.1.1/8",
+			removeCommand: "ifconfig",
+			removeArgs:    []string{networkInterface, "127.0.0.1", "up"},
+		},
+		{
+			name:          "ip only",
+			commands:      []string{"ip"},
+			addCommand:    "ip",
+			addArgs:       []string{"addr", "add", "127.0.1.1/32", "dev", networkInterface},
+			assignedCIDR:  "127.0.1.1/32",
+			removeCommand: "ip",
+			removeArgs:    []string{"addr", "del", "127.0.1.1/32", "dev", networkInterface},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Given
+			mockLookPath(t, testCase.commands...)
+
+			// When
+			addCommand, addArgs := getAddIPCommandWithArgs("127.0.1.1")
+			removeCommand, removeArgs := getRemoveIPCommandWithArgs(networkInterface, testCase.assignedCIDR)
+
+			// Then
+			assert.Equal(t, testCase.addCommand, addCommand)
+			assert.Equal(t, testCase.addArgs, addArgs)
+			assert.Equal(t, testCase.removeCommand, removeCommand)
+			assert.Equal(t, testCase.removeArgs, removeArgs)
+		})
+	}
+}
+
+func mockLookPath(t *testing.T, commands ...string) {
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		for _, command := range commands {
+			if command == file {
+				return "/usr/sbin/" + file, nil
+			}
+		}
+
+		return "", &exec.Error{Name: file, Err: errors.New("executable file not found in $PATH")}
+	}
+	t.Cleanup(func() { lookPath = original })
+}
@@ -1,14 +1,18 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"fmt"
-	"io"
 	"net"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"github.com/eko/monday/pkg/hostfile"
+	"github.com/eko/monday/pkg/inspect"
+	"github.com/eko/monday/pkg/state"
 	"github.com/eko/monday/pkg/ui"
 )
 
@@ -26,61 +30,97 @@ type Proxy interface {
 
 // proxy represents the proxy component instance
 type proxy struct {
-	ProxyForwards      map[string][]*ProxyForward
-	hostfile           hostfile.Hostfile
-	listeners          map[string]net.Listener
-	listening          bool
-	addProxyForwardMux sync.Mutex
-	listenerMux        sync.Mutex
-	latestPort         string
-	lastIpByteA        byte
-	lastIpByteB        byte
-	lastIpByteC        byte
-	lastIpByteD        byte
-	attributedIPs      map[string]string
-	view               ui.View
+	ProxyForwards        map[string][]*ProxyForward
+	hostfile             hostfile.Hostfile
+	listeners            map[string]net.Listener
+	listening            bool
+	addProxyForwardMux   sync.Mutex
+	listenerMux          sync.Mutex
+	latestPort           string
+	lastIpByteA          byte
+	lastIpByteB          byte
+	lastIpByteC          byte
+	lastIpByteD          byte
+	attributedIPs        map[string]string
+	addedIPs             []string
+	virtualHosts         map[string]map[string]*ProxyForward
+	virtualHostListeners map[string]bool
+	certificateAuthority *CertificateAuthority
+	inspector            *inspect.Inspector
+	faultsDisabled       atomic.Bool
+	started              bool
+	view                 ui.View
 }
 
 // NewProxy initializes a new proxy component instance
 func NewProxy(view ui.View, hostfile hostfile.Hostfile) *proxy {
 	return &proxy{
-		ProxyForwards: make(map[string][]*ProxyForward, 0),
-		hostfile:      hostfile,
-		listeners:     make(map[string]net.Listener),
-		listening:     true,
-		latestPort:    ProxyPortStart,
-		lastIpByteA:   127,
-		lastIpByteB:   0,
-		lastIpByteC:   1,
-		lastIpByteD:   0,
-		attributedIPs: make(map[string]string),
-		view:          view,
+		ProxyForwards:        make(map[string][]*ProxyForward, 0),
+		hostfile:             hostfile,
+		listeners:            make(map[string]net.Listener),
+		listening:            true,
+		latestPort:           ProxyPortStart,
+		lastIpByteA:          127,
+		lastIpByteB:          0,
+		lastIpByteC:          1,
+		lastIpByteD:          0,
+		attributedIPs:        make(map[string]string),
+		addedIPs:             make([]string, 0),
+		virtualHosts:         make(map[string]map[string]*ProxyForward),
+		virtualHostListeners: make(map[string]bool),
+		view:                 view,
 	}
 }
 
-// Listen opens a TCP proxy for each ProxyForward instance
+// Listen opens a TCP proxy for each ProxyForward instance. Forwards added afterwards are listened to
+// as soon as they are added.
 func (p *proxy) Listen() error {
+	p.addProxyForwardMux.Lock()
+	defer p.addProxyForwardMux.Unlock()
+
+	p.started = true
+
 	for name, pfs := range p.ProxyForwards {
 		for _, pf := range pfs {
-			if pf.LocalPort == "" {
-				// In case no local port is specified: don't handle connections
-				continue
-			}
+			p.listen(name, pf)
+		}
+	}
 
-			key := fmt.Sprintf("%s_%s", name, pf.LocalPort)
+	return nil
+}
 
-			// We already have a listening port
-			if _, ok := p.listeners[key]; ok {
-				return nil
-			}
+func (p *proxy) listen(name string, pf *ProxyForward) {
+	if pf.LocalPort == "" {
+		// In case no local port is specified: don't handle connections
+		return
+	}
 
-			p.view.Writef("🔌  Proxifying %s locally (%s:%s) <-> forwarding to %s:%s\n", pf.GetHostname(), pf.LocalIP, pf.LocalPort, pf.GetProxyHostname(), pf.ProxyPort)
+	// Virtual host forwards share a single listener per local port
+	if pf.VirtualHost {
+		p.view.Writef("🔌  Proxifying %s locally (%s:%s) <-> forwarding to %s:%s\n", pf.GetHostname(), pf.LocalIP, pf.LocalPort, pf.GetProxyHostname(), pf.ProxyPort)
 
-			go p.handleConnections(pf, key)
+		key := fmt.Sprintf("virtual-host_%s", pf.LocalPort)
+		if !p.virtualHostListeners[key] {
+			p.virtualHostListeners[key] = true
+			go p.handleVirtualHostConnections(pf.LocalPort, key)
 		}
+		return
 	}
 
-	return nil
+	key := fmt.Sprintf("%s_%s", name, pf.LocalPort)
+
+	// We already have a listening port
+	p.listenerMux.Lock()
+	_, ok := p.listeners[key]
+	p.listenerMux.Unlock()
+
+	if ok {
+		return
+	}
+
+	p.view.Writef("🔌  Proxifying %s locally (%s:%s) <-> forwarding to %s:%s\n", pf.GetHostname(), pf.LocalIP, pf.LocalPort, pf.GetProxyHostname(), pf.ProxyPort)
+
+	go p.handleConnections(pf, key)
 }
 
 // Stop stops all currently active proxy listeners
@@ -103,11 +143,20 @@ func (p *proxy) Stop() error {
 		}
 	}
 
+	for _, ip := range p.addedIPs {
+		if err := RemoveIPAlias(networkInterface, ip); err != nil {
+			p.view.Writef("❌  An error has occured while trying to remove IP address '%s': %v\n", ip, err)
+			continue
+		}
+
+		state.Current.RemoveIPAlias(ip)
+	}
+
 	return nil
 }
 
 func (p *proxy) handleConnections(pf *ProxyForward, key string) {
-	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%s", pf.LocalIP, pf.LocalPort))
+	listener, err := net.Listen("tcp", net.JoinHostPort(pf.LocalIP, pf.LocalPort))
 	if err != nil {
 		p.view.Writef("❌  Could not create proxy listener for '%s:%s' (%s): %v\n", pf.LocalIP, pf.LocalPort, pf.GetHostname(), err)
 		return
@@ -117,6 +166,11 @@ func (p *proxy) handleConnections(pf *ProxyForward, key string) {
 	p.listeners[key] = listener
 	p.listenerMux.Unlock()
 
+	// TLS is terminated here so that plaintext is forwarded
+	if ca := p.getCertificateAuthority(); pf.TLS && ca != nil {
+		listener = tls.NewListener(listener, ca.TLSConfig(pf.GetHostname()))
+	}
+
 	// Accept clients and proxify calls
 	for {
 		client, err := listener.Accept()
@@ -130,17 +184,72 @@ func (p *proxy) handleConnections(pf *ProxyForward, key string) {
 
 		defer client.Close()
 
-		target, err := net.Dial("tcp", fmt.Sprintf("%s:%s", pf.GetProxyHostname(), pf.ProxyPort))
+		target, err := net.Dial("tcp", net.JoinHostPort(pf.GetProxyHostname(), pf.ProxyPort))
 		if err != nil {
 			p.view.Writef("❌  Error when dialing with target for '%s:%s' (%s): %v\n", pf.GetProxyHostname(), pf.LocalPort, pf.ProxyPort, err)
 			return
 		}
 
-		defer target.Close()
+		go func() {
+			defer client.Close()
+			defer target.Close()
+
+			p.pipe(pf, client, target)
+		}()
+	}
+}
+
+// pipe proxifies data between the given client and target connections until one of them closes the
+// connection. HTTP exchanges are captured when the forward is inspected, and HTTP requests are parsed only
+// once when some of them are also answered with injected errors.
+func (p *proxy) pipe(pf *ProxyForward, client, target net.Conn) {
+	var hooks []inspect.Hook
+
+	// Inspector comes first so that it captures the HTTP errors injected as faults
+	if inspector := p.getInspector(); pf.Inspect && inspector != nil {
+		scheme := "http"
+		if _, ok := client.(*tls.Conn); ok {
+			scheme = "https"
+		}
+
+		hooks = append(hooks, inspector.Hook(pf.Name, net.JoinHostPort(pf.GetProxyHostname(), pf.ProxyPort), scheme))
+	}
+
+	if pf.Faults != nil {
+		faultyTarget, ok := p.injectFaults(pf, client, target)
+		if !ok {
+			return
+		}
+		defer faultyTarget.Close()
+
+		target = faultyTarget
 
-		go io.Copy(client, target)
-		go io.Copy(target, client)
+		if pf.Faults.ErrorRate > 0 {
+			hooks = append(hooks, injectHTTPErrors(pf.Faults, p.areFaultsEnabled))
+		}
 	}
+
+	if len(hooks) == 0 {
+		inspect.Pipe(client, client, target, target)
+		return
+	}
+
+	inspect.ProxyHTTP(client, target, inspect.ChainHooks(hooks...))
+}
+
+// SetInspector sets the inspector capturing the HTTP exchanges of inspected forwards
+func (p *proxy) SetInspector(inspector *inspect.Inspector) {
+	p.addProxyForwardMux.Lock()
+	defer p.addProxyForwardMux.Unlock()
+
+	p.inspector = inspector
+}
+
+func (p *proxy) getInspector() *inspect.Inspector {
+	p.addProxyForwardMux.Lock()
+	defer p.addProxyForwardMux.Unlock()
+
+	return p.inspector
 }
 
 // AddProxyForward creates a new ProxyForward instance and attributes an IP address and a proxy port to it
@@ -148,8 +257,13 @@ func (p *proxy) AddProxyForward(name string, proxyForward *ProxyForward) {
 	p.addProxyForwardMux.Lock()
 	defer p.addProxyForwardMux.Unlock()
 
-	err := p.generateIP(proxyForward)
-	if err != nil {
+	if proxyForward.TLS {
+		p.loadCertificateAuthority()
+	}
+
+	if proxyForward.VirtualHost {
+		p.addVirtualHost(proxyForward)
+	} else if err := p.generateIP(proxyForward); err != nil {
 		p.view.Writef("❌  An error has occured while generating IP address for '%s': %v\n", proxyForward.Name, err)
 	}
 
@@ -163,11 +277,47 @@ func (p *proxy) AddProxyForward(name string, proxyForward *ProxyForward) {
 		p.view.Writef("✅  Successfully mapped hostname '%s' with IP '%s'\n", proxyForward.GetHostname(), proxyForward.LocalIP)
 	}
 
+	if proxyForward.Faults != nil {
+		p.view.Writef("💥  Faults will be injected into connections to '%s': %s\n", proxyForward.GetHostname(), proxyForward.Faults)
+	}
+
 	if pfs, ok := p.ProxyForwards[name]; ok {
 		p.ProxyForwards[name] = append(pfs, proxyForward)
 	} else {
 		p.ProxyForwards[name] = append(pfs, proxyForward)
 	}
+
+	if p.started {
+		p.listen(name, proxyForward)
+	}
+}
+
+func (p *proxy) getCertificateAuthority() *CertificateAuthority {
+	p.addProxyForwardMux.Lock()
+	defer p.addProxyForwardMux.Unlock()
+
+	return p.certificateAuthority
+}
+
+// loadCertificateAuthority loads the local certificate authority the first time a forward needs TLS
+func (p *proxy) loadCertificateAuthority() {
+	if p.certificateAuthority != nil {
+		return
+	}
+
+	path := GetCAPath()
+
+	ca, created, err := LoadCertificateAuthority(path)
+	if err != nil {
+		p.view.Writef("❌  Unable to load certificate authority, TLS will not be terminated: %v\n", err)
+		return
+	}
+
+	if created {
+		p.view.Writef("🔐  Certificate authority has been created, please trust '%s' in your system or browser to use HTTPS hostnames\n", filepath.Join(path, caCertificateFile))
+	}
+
+	p.certificateAuthority = ca
 }
 
 func (p *proxy) generateIP(pf *ProxyForward) error {
@@ -185,7 +335,7 @@ func (p *proxy) generateIP(pf *ProxyForward) error {
 	p.lastIpByteC = c
 	p.lastIpByteD = d
 
-	a, b, c, d, err = assignIpToPort(a, b, c, d, pf.LocalPort)
+	a, b, c, d, err = p.assignIpToPort(a, b, c, d, pf.LocalPort)
 	if err != nil {
 		return err
 	}
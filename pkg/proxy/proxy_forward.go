@@ -10,6 +10,10 @@ type ProxyForward struct {
 	ForwardPort   string
 	LocalIP       string
 	ProxyPort     string
+	VirtualHost   bool
+	TLS           bool
+	Inspect       bool
+	Faults        *Faults
 }
 
 // NewProxyForward returns a new proxy port-forward instance
@@ -41,6 +45,28 @@ func (p *ProxyForward) SetProxyPort(port string) {
 	p.ProxyPort = port
 }
 
+// SetVirtualHost sets whether this forward shares its local port with other HTTP forwards, the
+// connections being routed to it from their Host header (or TLS server name)
+func (p *ProxyForward) SetVirtualHost(virtualHost bool) {
+	p.VirtualHost = virtualHost
+}
+
+// SetTLS sets whether TLS connections to this forward are terminated by the proxy, using a certificate
+// issued by the local certificate authority
+func (p *ProxyForward) SetTLS(tls bool) {
+	p.TLS = tls
+}
+
+// SetInspect sets whether the HTTP exchanges going through this forward are captured by the inspector
+func (p *ProxyForward) SetInspect(inspect bool) {
+	p.Inspect = inspect
+}
+
+// SetFaults sets the faults injected by the proxy into the connections of this forward
+func (p *ProxyForward) SetFaults(faults *Faults) {
+	p.Faults = faults
+}
+
 // GetProxifiedPorts returns the couple of proxified ports (proxy attributed port:forward port)
 func (p *ProxyForward) GetProxifiedPorts() string {
 	return fmt.Sprintf("%s:%s", p.ProxyPort, p.ForwardPort)
@@ -0,0 +1,233 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/eko/monday/pkg/hostfile"
+)
+
+const (
+	// VirtualHostIP is the IP address shared by all the hostnames of virtual host forwards
+	VirtualHostIP = "127.0.0.1"
+
+	// tlsRecordTypeHandshake is the first byte sent by a TLS client
+	tlsRecordTypeHandshake = 0x16
+)
+
+var (
+	// virtualHostReadTimeout is the time given to clients to send the hostname they want to reach
+	virtualHostReadTimeout = 10 * time.Second
+
+	errClientHelloRead = errors.New("client hello has been read")
+)
+
+// addVirtualHost maps the hostname of the given forward with the shared virtual host IP address and
+// registers it as a route of its local port
+func (p *proxy) addVirtualHost(pf *ProxyForward) {
+	pf.SetLocalIP(VirtualHostIP)
+
+	if _, ok := p.attributedIPs[pf.GetHostname()]; !ok {
+		p.attributedIPs[pf.GetHostname()] = VirtualHostIP
+
+		if err := p.hostfile.AddHost(VirtualHostIP, pf.GetHostname()); err != nil {
+			p.view.Writef("❌  An error has occured while trying to write host file for application '%s' (ip: %s): %v\n", pf.Name, pf.LocalIP, err)
+		}
+
+		// Also add a ::1 entry for IPv6 on macOS, see generateIP()
+		if runtime.GOOS == "darwin" {
+			if err := p.hostfile.AddHost("::1", pf.GetHostname()); err != nil {
+				p.view.Writef("❌  An error has occured while trying to write host file for application '%s' (ip: %s): %v\n", pf.Name, pf.LocalIP, err)
+			}
+		}
+	}
+
+	if pf.LocalPort == "" {
+		return
+	}
+
+	if _, ok := p.virtualHosts[pf.LocalPort]; !ok {
+		p.virtualHosts[pf.LocalPort] = make(map[string]*ProxyForward)
+	}
+
+	p.virtualHosts[pf.LocalPort][strings.ToLower(pf.GetHostname())] = pf
+}
+
+// getVirtualHost returns the forward serving the given hostname on the given local port
+func (p *proxy) getVirtualHost(port, hostname string) (*ProxyForward, bool) {
+	p.addProxyForwardMux.Lock()
+	defer p.addProxyForwardMux.Unlock()
+
+	if pf, ok := p.virtualHosts[port][strings.ToLower(hostname)]; ok {
+		return pf, true
+	}
+
+	// Wildcard hostnames (like *.svc.local) serve all their subdomains
+	for pattern, pf := range p.virtualHosts[port] {
+		if hostfile.MatchHostname(pattern, hostname) {
+			return pf, true
+		}
+	}
+
+	return nil, false
+}
+
+// handleVirtualHostConnections accepts the connections of all the virtual host forwards sharing the
+// given local port
+func (p *proxy) handleVirtualHostConnections(port, key string) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(VirtualHostIP, port))
+	if err != nil {
+		p.view.Writef("❌  Could not create virtual host listener for '%s:%s': %v\n", VirtualHostIP, port, err)
+		return
+	}
+
+	p.listenerMux.Lock()
+	p.listeners[key] = listener
+	p.listenerMux.Unlock()
+
+	for {
+		client, err := listener.Accept()
+		if !p.listening {
+			break
+		}
+		if err != nil {
+			p.view.Writef("❌  Could not accept client connection for '%s:%s': %v\n", VirtualHostIP, port, err)
+			return
+		}
+
+		go p.proxyVirtualHost(client, port)
+	}
+}
+
+// proxyVirtualHost reads the hostname the client wants to reach (from the Host header of HTTP
+// requests or the server name of TLS handshakes) and proxifies the connection to its forward.
+// Read data are replayed to the forward so the connection is left untouched, unless TLS is terminated.
+func (p *proxy) proxyVirtualHost(client net.Conn, port string) {
+	defer client.Close()
+
+	var received bytes.Buffer
+	reader := bufio.NewReader(io.TeeReader(client, &received))
+
+	client.SetReadDeadline(time.Now().Add(virtualHostReadTimeout))
+	hostname, isTLS, err := readHostname(reader)
+	client.SetReadDeadline(time.Time{})
+
+	if err != nil {
+		p.view.Writef("❌  Unable to read hostname of client connection on port %s: %v\n", port, err)
+		return
+	}
+
+	pf, ok := p.getVirtualHost(port, hostname)
+	if !ok {
+		p.view.Writef("❌  No forward matches hostname '%s' on port %s\n", hostname, port)
+
+		if !isTLS {
+			body := fmt.Sprintf("No forward matches hostname '%s' on port %s\n", hostname, port)
+			fmt.Fprintf(client, "HTTP/1.1 404 Not Found\r\nContent-Type: text/plain\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s", len(body), body)
+		}
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(pf.GetProxyHostname(), pf.ProxyPort))
+	if err != nil {
+		p.view.Writef("❌  Error when dialing with target for '%s:%s' (%s): %v\n", pf.GetProxyHostname(), pf.LocalPort, pf.ProxyPort, err)
+		return
+	}
+	defer target.Close()
+
+	// Read data are replayed to the forward
+	client = &replayConn{Conn: client, reader: io.MultiReader(&received, client)}
+
+	if ca := p.getCertificateAuthority(); isTLS && pf.TLS && ca != nil {
+		// TLS is terminated here so that plaintext is forwarded
+		tlsClient := tls.Server(client, ca.TLSConfig(pf.GetHostname()))
+		defer tlsClient.Close()
+
+		client = tlsClient
+	}
+
+	p.pipe(pf, client, target)
+}
+
+// readHostname returns the hostname a client wants to reach and whether it is a TLS connection
+func readHostname(reader *bufio.Reader) (string, bool, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return "", false, err
+	}
+
+	if first[0] == tlsRecordTypeHandshake {
+		hostname, err := readServerName(reader)
+		return hostname, true, err
+	}
+
+	request, err := http.ReadRequest(reader)
+	if err != nil {
+		return "", false, err
+	}
+
+	return stripPort(request.Host), false, nil
+}
+
+// readServerName reads the TLS client hello and returns the server name (SNI) it asks for
+func readServerName(reader io.Reader) (string, error) {
+	var serverName string
+
+	err := tls.Server(readOnlyConn{reader: reader}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, errClientHelloRead
+		},
+	}).Handshake()
+
+	if !errors.Is(err, errClientHelloRead) {
+		return "", err
+	}
+
+	if serverName == "" {
+		return "", errors.New("no server name has been given in TLS handshake")
+	}
+
+	return serverName, nil
+}
+
+func stripPort(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return hostname
+	}
+
+	return host
+}
+
+// readOnlyConn is a connection that can only be read, used to read TLS handshakes without answering them
+type readOnlyConn struct {
+	reader io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error)         { return c.reader.Read(p) }
+func (c readOnlyConn) Write(p []byte) (int, error)        { return 0, io.ErrClosedPipe }
+func (c readOnlyConn) Close() error                       { return nil }
+func (c readOnlyConn) LocalAddr() net.Addr                { return nil }
+func (c readOnlyConn) RemoteAddr() net.Addr               { return nil }
+func (c readOnlyConn) SetDeadline(t time.Time) error      { return nil }
+func (c readOnlyConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c readOnlyConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// replayConn is a connection whose first read data are the ones given by its reader
+type replayConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
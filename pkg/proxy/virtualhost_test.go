@@ -0,0 +1,280 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/hostfile"
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAddProxyForwardWhenVirtualHost(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hostfileMock := hostfile.NewMockHostfile(ctrl)
+	hostfileMock.EXPECT().AddHost("127.0.0.1", "api.svc.local").Return(nil)
+	hostfileMock.EXPECT().AddHost("127.0.0.1", "graphql.svc.local").Return(nil)
+	hostfileMock.EXPECT().AddHost("::1", gomock.Any()).Return(nil).AnyTimes()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("✅  Successfully mapped hostname '%s' with IP '%s' and port %s\n", "api.svc.local", "127.0.0.1", "9401")
+	view.EXPECT().Writef("✅  Successfully mapped hostname '%s' with IP '%s' and port %s\n", "graphql.svc.local", "127.0.0.1", "9402")
+
+	proxy := NewProxy(view, hostfileMock)
+
+	apiForward := NewProxyForward("api", "api.svc.local", "", "8080", "8080")
+	apiForward.SetVirtualHost(true)
+
+	graphqlForward := NewProxyForward("graphql", "graphql.svc.local", "", "8080", "8000")
+	graphqlForward.SetVirtualHost(true)
+
+	// When
+	proxy.AddProxyForward("api", apiForward)
+	proxy.AddProxyForward("graphql", graphqlForward)
+
+	// Then
+	assert.Equal(t, "127.0.0.1", apiForward.LocalIP)
+	assert.Equal(t, "127.0.0.1", graphqlForward.LocalIP)
+
+	assert.Equal(t, map[string]*ProxyForward{
+		"api.svc.local":     apiForward,
+		"graphql.svc.local": graphqlForward,
+	}, proxy.virtualHosts["8080"])
+
+	// IP addresses of the loopback interface are left untouched
+	assert.Empty(t, proxy.addedIPs)
+}
+
+func TestProxyVirtualHostWithHTTP(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	apiTarget, apiConnections := newVirtualHostTarget(t)
+	graphqlTarget, graphqlConnections := newVirtualHostTarget(t)
+
+	proxy := newVirtualHostProxy(view, map[string]string{
+		"api.svc.local":     apiTarget,
+		"graphql.svc.local": graphqlTarget,
+	})
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	// When
+	go proxy.proxyVirtualHost(server, "8080")
+
+	go client.Write([]byte("GET /query HTTP/1.1\r\nHost: graphql.svc.local:8080\r\n\r\n"))
+
+	// Then
+	select {
+	case received := <-graphqlConnections:
+		assert.Equal(t, "GET /query HTTP/1.1\r\nHost: graphql.svc.local:8080\r\n\r\n", received)
+	case <-apiConnections:
+		t.Fatal("Request has been routed to the wrong forward")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Request has not been routed")
+	}
+}
+
+func TestProxyVirtualHostWithTLS(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	apiTarget, apiConnections := newVirtualHostTarget(t)
+	graphqlTarget, graphqlConnections := newVirtualHostTarget(t)
+
+	proxy := newVirtualHostProxy(view, map[string]string{
+		"api.svc.local":     apiTarget,
+		"graphql.svc.local": graphqlTarget,
+	})
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	// When
+	go proxy.proxyVirtualHost(server, "8443")
+
+	go tls.Client(client, &tls.Config{ServerName: "api.svc.local"}).Handshake()
+
+	// Then
+	select {
+	case received := <-apiConnections:
+		assert.Equal(t, byte(tlsRecordTypeHandshake), received[0])
+	case <-graphqlConnections:
+		t.Fatal("Connection has been routed to the wrong forward")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Connection has not been routed")
+	}
+}
+
+func TestProxyVirtualHostWithTLSTermination(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+
+	apiTarget, apiConnections := newVirtualHostTarget(t)
+
+	proxy := newVirtualHostProxy(view, map[string]string{"api.svc.local": apiTarget})
+	proxy.virtualHosts["8443"]["api.svc.local"].SetTLS(true)
+
+	ca, _, err := LoadCertificateAuthority(t.TempDir())
+	assert.Nil(t, err)
+	proxy.certificateAuthority = ca
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	// When
+	go proxy.proxyVirtualHost(server, "8443")
+
+	tlsClient := tls.Client(client, &tls.Config{ServerName: "api.svc.local", RootCAs: roots})
+	go tlsClient.Write([]byte("GET / HTTP/1.1\r\nHost: api.svc.local\r\n\r\n"))
+
+	// Then
+	select {
+	case received := <-apiConnections:
+		assert.Equal(t, "GET / HTTP/1.1\r\nHost: api.svc.local\r\n\r\n", received)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Request has not been forwarded in plaintext")
+	}
+}
+
+func TestProxyVirtualHostWhenUnknownHostname(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("❌  No forward matches hostname '%s' on port %s\n", "unknown.svc.local", "8080")
+
+	proxy := newVirtualHostProxy(view, map[string]string{})
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	// When
+	go proxy.proxyVirtualHost(server, "8080")
+
+	go client.Write([]byte("GET / HTTP/1.1\r\nHost: unknown.svc.local\r\n\r\n"))
+
+	// Then
+	response, err := http.ReadResponse(bufio.NewReader(client), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestGetVirtualHostWhenWildcard(t *testing.T) {
+	// Given
+	proxy := newVirtualHostProxy(nil, map[string]string{
+		"*.svc.local":        "9401",
+		"user-api.svc.local": "9402",
+	})
+
+	// When
+	wildcard, wildcardOk := proxy.getVirtualHost("8080", "graphql.svc.local")
+	exact, exactOk := proxy.getVirtualHost("8080", "user-api.svc.local")
+	_, unknownOk := proxy.getVirtualHost("8080", "graphql.acme.tld")
+
+	// Then
+	assert.True(t, wildcardOk)
+	assert.Equal(t, "9401", wildcard.ProxyPort)
+
+	assert.True(t, exactOk)
+	assert.Equal(t, "9402", exact.ProxyPort)
+
+	assert.False(t, unknownOk)
+}
+
+func TestReadHostname(t *testing.T) {
+	testCases := []struct {
+		request          string
+		expectedHostname string
+	}{
+		{request: "GET / HTTP/1.1\r\nHost: api.svc.local\r\n\r\n", expectedHostname: "api.svc.local"},
+		{request: "POST /query HTTP/1.1\r\nHost: graphql.svc.local:8080\r\nContent-Length: 0\r\n\r\n", expectedHostname: "graphql.svc.local"},
+	}
+
+	for _, testCase := range testCases {
+		// When
+		hostname, isTLS, err := readHostname(bufio.NewReader(strings.NewReader(testCase.request)))
+
+		// Then
+		assert.Nil(t, err)
+		assert.False(t, isTLS)
+		assert.Equal(t, testCase.expectedHostname, hostname)
+	}
+}
+
+func newVirtualHostProxy(view ui.View, targets map[string]string) *proxy {
+	p := &proxy{view: view, virtualHosts: make(map[string]map[string]*ProxyForward)}
+
+	for _, port := range []string{"8080", "8443"} {
+		p.virtualHosts[port] = make(map[string]*ProxyForward)
+
+		for hostname, target := range targets {
+			pf := NewProxyForward(hostname, hostname, "", port, port)
+			pf.SetProxyPort(target)
+			pf.SetVirtualHost(true)
+
+			p.virtualHosts[port][hostname] = pf
+		}
+	}
+
+	return p
+}
+
+// newVirtualHostTarget listens on a local port and sends the first data received on each connection
+func newVirtualHostTarget(t *testing.T) (string, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			buffer := make([]byte, 4096)
+			n, err := conn.Read(buffer)
+			if err != nil && err != io.EOF {
+				conn.Close()
+				continue
+			}
+
+			received <- string(buffer[:n])
+			conn.Close()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	return port, received
+}
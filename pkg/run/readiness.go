@@ -0,0 +1,247 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/helper"
+	"github.com/eko/monday/pkg/log"
+)
+
+const (
+	defaultProbeInterval = 1 * time.Second
+	defaultProbeTimeout  = 5 * time.Second
+)
+
+// probe represents a readiness check that can be run multiple times until it succeeds
+type probe interface {
+	String() string
+	Settings() config.ProbeSettings
+	Check(ctx context.Context) error
+}
+
+// newProbes builds the probes declared in the readiness section of an application.
+// The log probe is registered as a listener of the given streamers.
+func newProbes(application *config.Application, streamers ...*log.Streamer) ([]probe, error) {
+	var readiness = application.Readiness
+	var probes = make([]probe, 0)
+
+	if readiness == nil {
+		return probes, nil
+	}
+
+	if readiness.TCP != nil {
+		probes = append(probes, &tcpProbe{readiness.TCP})
+	}
+
+	if readiness.HTTP != nil {
+		probes = append(probes, &httpProbe{readiness.HTTP})
+	}
+
+	if readiness.Log != nil {
+		pattern, err := regexp.Compile(readiness.Log.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log readiness pattern '%s' for application '%s': %v", readiness.Log.Pattern, application.Name, err)
+		}
+
+		logProbe := &logProbe{conf: readiness.Log, pattern: pattern}
+		for _, streamer := range streamers {
+			streamer.AddListener(logProbe.match)
+		}
+
+		probes = append(probes, logProbe)
+	}
+
+	if readiness.Command != nil {
+		probes = append(probes, &commandProbe{conf: readiness.Command, path: application.GetPath()})
+	}
+
+	return probes, nil
+}
+
+// waitForProbes runs all the given probes until they all succeed. It returns false in case
+// the given context is done before.
+func waitForProbes(ctx context.Context, probes []probe) bool {
+	var wg sync.WaitGroup
+	var failed int32
+
+	for _, p := range probes {
+		wg.Add(1)
+
+		go func(p probe) {
+			defer wg.Done()
+
+			if !waitForProbe(ctx, p) {
+				atomic.StoreInt32(&failed, 1)
+			}
+		}(p)
+	}
+
+	wg.Wait()
+
+	return atomic.LoadInt32(&failed) == 0
+}
+
+func waitForProbe(ctx context.Context, p probe) bool {
+	settings := p.Settings()
+
+	interval := settings.Interval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := p.Check(checkCtx)
+		cancel()
+
+		if err == nil {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(interval):
+		}
+	}
+}
+
+type tcpProbe struct {
+	conf *config.TCPProbe
+}
+
+func (p *tcpProbe) String() string {
+	return fmt.Sprintf("tcp %s", p.conf.Address)
+}
+
+func (p *tcpProbe) Settings() config.ProbeSettings {
+	return p.conf.ProbeSettings
+}
+
+func (p *tcpProbe) Check(ctx context.Context) error {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", p.conf.Address)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+type httpProbe struct {
+	conf *config.HTTPProbe
+}
+
+func (p *httpProbe) String() string {
+	return fmt.Sprintf("http %s", p.conf.URL)
+}
+
+func (p *httpProbe) Settings() config.ProbeSettings {
+	return p.conf.ProbeSettings
+}
+
+func (p *httpProbe) Check(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.conf.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if p.conf.Status != 0 {
+		if response.StatusCode != p.conf.Status {
+			return fmt.Errorf("unexpected status code %d (expected %d)", response.StatusCode, p.conf.Status)
+		}
+
+		return nil
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+type logProbe struct {
+	conf    *config.LogProbe
+	pattern *regexp.Regexp
+	matched int32
+}
+
+func (p *logProbe) String() string {
+	return fmt.Sprintf("log /%s/", p.conf.Pattern)
+}
+
+func (p *logProbe) Settings() config.ProbeSettings {
+	return p.conf.ProbeSettings
+}
+
+func (p *logProbe) Check(_ context.Context) error {
+	if atomic.LoadInt32(&p.matched) == 0 {
+		return fmt.Errorf("pattern '%s' not found in logs", p.conf.Pattern)
+	}
+
+	return nil
+}
+
+func (p *logProbe) match(line string) {
+	if p.pattern.MatchString(line) {
+		atomic.StoreInt32(&p.matched, 1)
+	}
+}
+
+type commandProbe struct {
+	conf *config.CommandProbe
+	path string
+}
+
+func (p *commandProbe) String() string {
+	return fmt.Sprintf("command '%s'", p.conf.Command)
+}
+
+func (p *commandProbe) Settings() config.ProbeSettings {
+	return p.conf.ProbeSettings
+}
+
+func (p *commandProbe) Check(ctx context.Context) error {
+	cmd := helper.BuildCmd([]string{p.conf.Command}, p.path, nil, nil)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		// Commands are run in their own process group so we can kill them all
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-result
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,177 @@
+package run
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	"github.com/eko/monday/pkg/log"
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTCPProbe(t *testing.T) {
+	// Given
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	probe := &tcpProbe{&config.TCPProbe{Address: listener.Addr().String()}}
+
+	// When
+	err = probe.Check(context.Background())
+
+	// Then
+	assert.Nil(t, err)
+}
+
+func TestTCPProbeWhenClosed(t *testing.T) {
+	// Given
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	probe := &tcpProbe{&config.TCPProbe{Address: address}}
+
+	// When
+	err = probe.Check(context.Background())
+
+	// Then
+	assert.NotNil(t, err)
+}
+
+func TestHTTPProbe(t *testing.T) {
+	// Given
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/health" {
+			res.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		res.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	testCases := []struct {
+		url      string
+		status   int
+		expected bool
+	}{
+		{url: testServer.URL + "/health", status: 0, expected: true},
+		{url: testServer.URL + "/health", status: http.StatusNoContent, expected: true},
+		{url: testServer.URL + "/health", status: http.StatusOK, expected: false},
+		{url: testServer.URL + "/unavailable", status: 0, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		probe := &httpProbe{&config.HTTPProbe{URL: testCase.url, Status: testCase.status}}
+
+		// When
+		err := probe.Check(context.Background())
+
+		// Then
+		assert.Equal(t, testCase.expected, err == nil)
+	}
+}
+
+func TestLogProbe(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Write(gomock.Any()).Times(2)
+
+	streamer := log.NewStreamer(log.StdOut, "test-app", view)
+
+	application := &config.Application{
+		Name: "test-app",
+		Readiness: &config.Readiness{
+			Log: &config.LogProbe{Pattern: "listening on port [0-9]+"},
+		},
+	}
+
+	probes, err := newProbes(application, streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When - Then
+	streamer.Write([]byte("starting application\n"))
+	assert.NotNil(t, probes[0].Check(context.Background()))
+
+	streamer.Write([]byte("listening on port 8080\n"))
+	assert.Nil(t, probes[0].Check(context.Background()))
+}
+
+func TestLogProbeWhenInvalidPattern(t *testing.T) {
+	// Given
+	application := &config.Application{
+		Name: "test-app",
+		Readiness: &config.Readiness{
+			Log: &config.LogProbe{Pattern: "(unclosed"},
+		},
+	}
+
+	// When
+	probes, err := newProbes(application)
+
+	// Then
+	assert.Nil(t, probes)
+	assert.Contains(t, err.Error(), "invalid log readiness pattern '(unclosed' for application 'test-app'")
+}
+
+func TestCommandProbe(t *testing.T) {
+	// Given
+	testCases := []struct {
+		command  string
+		expected bool
+	}{
+		{command: "true", expected: true},
+		{command: "exit 1", expected: false},
+		{command: "sleep 5", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		probe := &commandProbe{conf: &config.CommandProbe{Command: testCase.command}, path: "/"}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+
+		// When
+		err := probe.Check(ctx)
+		cancel()
+
+		// Then
+		assert.Equal(t, testCase.expected, err == nil)
+	}
+}
+
+func TestWaitForProbesWhenContextDone(t *testing.T) {
+	// Given
+	failingProbe := &commandProbe{
+		conf: &config.CommandProbe{
+			ProbeSettings: config.ProbeSettings{Interval: 10 * time.Millisecond},
+			Command:       "exit 1",
+		},
+		path: "/",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// When
+	ready := waitForProbes(ctx, []probe{failingProbe})
+
+	// Then
+	assert.False(t, ready)
+}
@@ -1,9 +1,15 @@
 package run
 
 import (
+	"context"
+	"errors"
 	"os/exec"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/eko/monday/internal/wait"
 	"github.com/eko/monday/pkg/config"
 	"github.com/eko/monday/pkg/helper"
 	"github.com/eko/monday/pkg/log"
@@ -11,11 +17,29 @@ import (
 	"github.com/eko/monday/pkg/ui"
 )
 
+const (
+	// backoffResetDelay is the running duration after which an application restart backoff is reset
+	backoffResetDelay = 1 * time.Minute
+)
+
+var (
+	errNoRunSection = errors.New("no run section declared")
+
+	newRestartBackoff = func() *wait.Backoff {
+		return &wait.Backoff{
+			Min:    1 * time.Second,
+			Max:    1 * time.Minute,
+			Factor: 2,
+		}
+	}
+)
+
 type Runner interface {
 	RunAll()
 	Run(application *config.Application)
 	Restart(application *config.Application)
 	Stop() error
+	GetStatus(name string) (Status, bool)
 }
 
 // runner is the struct that manage running local applications
@@ -24,8 +48,10 @@ type runner struct {
 	projectName  string
 	applications []*config.Application
 	cmds         map[string]*exec.Cmd
+	states       map[string]*state
 	view         ui.View
 	conf         *config.GlobalRun
+	mux          sync.Mutex
 }
 
 // NewRunner instanciates a Runner struct from configuration data
@@ -35,18 +61,46 @@ func NewRunner(view ui.View, proxy proxy.Proxy, project *config.Project, conf *c
 		projectName:  project.Name,
 		applications: project.Applications,
 		cmds:         make(map[string]*exec.Cmd, 0),
+		states:       make(map[string]*state, 0),
 		view:         view,
 		conf:         conf,
 	}
 }
 
-// RunAll runs all local applications in separated goroutines
+// RunAll runs all local applications in separated goroutines, in a topological order:
+// an application is only launched once all the applications it depends on are ready
 func (r *runner) RunAll() {
-	for _, application := range r.applications {
-		go r.Run(application)
+	applications, err := config.SortApplicationsByDependencies(r.applications)
+	if err != nil {
+		r.view.Writef("❌  %v\n", err)
+		return
+	}
+
+	// Initialize all states first so that dependents can wait on them
+	states := make(map[string]*state, len(applications))
+	for _, application := range applications {
+		states[application.Name] = r.newState(application.Name)
+	}
+
+	for _, application := range applications {
+		go r.runWhenDependenciesReady(application, states)
 
 		if application.Hostname != "" {
 			proxyForward := proxy.NewProxyForward(application.Name, application.Hostname, "", "", "")
+
+			// Application is served over HTTPS on its hostname, TLS being terminated by the proxy
+			if application.TLS {
+				proxyForward = proxy.NewProxyForward(application.Name, application.Hostname, "", proxy.TLSPort, application.Port)
+				proxyForward.SetProxyPort(application.Port)
+				proxyForward.SetTLS(true)
+			} else if application.Faults != nil {
+				// Application is reached through the proxy on its hostname so that faults can be injected
+				proxyForward = proxy.NewProxyForward(application.Name, application.Hostname, "", application.Port, application.Port)
+				proxyForward.SetProxyPort(application.Port)
+			}
+
+			proxyForward.SetFaults(proxy.NewFaults(application.Faults))
+
 			r.proxy.AddProxyForward(application.Name, proxyForward)
 		}
 	}
@@ -54,21 +108,86 @@ func (r *runner) RunAll() {
 
 // Run launches the application
 func (r *runner) Run(application *config.Application) {
+	r.start(application, r.newState(application.Name))
+}
+
+func (r *runner) runWhenDependenciesReady(application *config.Application, states map[string]*state) {
+	if len(application.DependsOn) > 0 {
+		r.view.Writef("⏳  Local app '%s' is waiting for its dependencies: %s\n", application.Name, strings.Join(application.DependsOn, ", "))
+	}
+
+	for _, name := range application.DependsOn {
+		dependency := states[name]
+
+		select {
+		case <-dependency.ready:
+		case <-dependency.done:
+			r.view.Writef("❌  Local app '%s' will not be launched because its dependency '%s' has stopped\n", application.Name, name)
+			states[application.Name].markDone()
+			return
+		}
+	}
+
+	r.start(application, states[application.Name])
+}
+
+func (r *runner) start(application *config.Application, st *state) {
+	defer st.markDone()
+
 	if err := helper.CheckPathExists(application.GetPath()); err != nil {
 		r.view.Writef("❌  %s\n", err.Error())
 		return
 	}
 
-	r.run(application)
+	backoff := newRestartBackoff()
+
+	for {
+		startedAt := time.Now()
+
+		exitCode, err := r.run(application, st)
+		if err != nil {
+			return
+		}
+
+		st.markExited(exitCode)
+
+		if st.isStopping() || !application.Run.ShouldRestart(exitCode) {
+			return
+		}
+
+		restarts := st.getStatus().Restarts
+		if application.Run.MaxRetries > 0 && restarts >= application.Run.MaxRetries {
+			r.view.Writef("💥  Local app '%s' is crash-looping, giving up after %d restarts (exit code: %d)\n", application.Name, restarts, exitCode)
+			st.markFailed()
+			return
+		}
+
+		// Application ran long enough to be considered healthy: do not wait too long
+		if time.Since(startedAt) > backoffResetDelay {
+			backoff.Reset()
+		}
+
+		delay := backoff.Duration()
+		st.markCrashLoopBackOff()
+
+		r.view.Writef("🔁  Local app '%s' exited with code %d, restarting in %s (restart #%d)...\n", application.Name, exitCode, delay, restarts+1)
+
+		select {
+		case <-time.After(delay):
+		case <-st.stopping:
+			return
+		}
+	}
 }
 
-// Run launches the application
-func (r *runner) run(application *config.Application) {
+// run launches the application process and waits for it to exit. It returns an error
+// in case the process could not be launched at all.
+func (r *runner) run(application *config.Application, st *state) (int, error) {
 	var run = application.Run
 
 	if run == nil {
 		r.view.Writef("❌  Please declare a 'run' section for application %s\n", application.Name)
-		return
+		return 0, errNoRunSection
 	}
 
 	r.view.Writef("🏁  Running local app '%s' (%s)...\n", application.Name, application.Path)
@@ -78,6 +197,12 @@ func (r *runner) run(application *config.Application) {
 	stdoutStream := log.NewStreamer(log.StdOut, application.Name, r.view)
 	stderrStream := log.NewStreamer(log.StdErr, application.Name, r.view)
 
+	probes, err := newProbes(application, stdoutStream, stderrStream)
+	if err != nil {
+		r.view.Writef("❌  %v\n", err)
+		return 0, err
+	}
+
 	cmd := helper.BuildCmd([]string{run.Command}, applicationPath, stdoutStream, stderrStream)
 
 	// Merge global environment variables with given ones
@@ -86,18 +211,97 @@ func (r *runner) run(application *config.Application) {
 		envs = helper.MergeMapString(run.Env, r.conf.Env)
 	}
 
+	// Values read from the cluster come first so that local ones override them
+	if err := helper.AddEnvVariablesFromCluster(cmd, run.EnvFrom); err != nil {
+		r.view.Writef("❌  %v\n", err)
+		return 0, err
+	}
+
 	helper.AddEnvVariables(cmd, envs)
 	if err := helper.AddEnvVariablesFromFile(cmd, run.GetEnvFile()); err != nil {
 		r.view.Writef("❌  %v\n", err)
-		return
+		return 0, err
 	}
 
+	exited := make(chan struct{})
+	defer close(exited)
+
+	r.mux.Lock()
 	r.cmds[application.Name] = cmd
+	st.setExitedChannel(exited)
+	r.mux.Unlock()
 
-	if err := cmd.Run(); err != nil {
+	if err := cmd.Start(); err != nil {
 		r.view.Writef("❌  Cannot run the application %s on path %s: %v\n", application.Name, applicationPath, err)
+		return 0, err
+	}
+
+	st.markStarting()
+
+	go r.waitForReadiness(application, st, probes, exited)
+
+	if err := cmd.Wait(); err != nil && !st.isStopping() {
+		r.view.Writef("❌  Cannot run the application %s on path %s: %v\n", application.Name, applicationPath, err)
+	}
+
+	return cmd.ProcessState.ExitCode(), nil
+}
+
+// waitForReadiness marks the application as ready once all its readiness probes succeed
+func (r *runner) waitForReadiness(application *config.Application, st *state, probes []probe, exited <-chan struct{}) {
+	if len(probes) == 0 {
+		st.markReady()
+		return
+	}
+
+	names := make([]string, 0, len(probes))
+	for _, p := range probes {
+		names = append(names, p.String())
+	}
+
+	r.view.Writef("⏳  Waiting for local app '%s' to be ready (%s)...\n", application.Name, strings.Join(names, ", "))
+
+	// Stop probing as soon as the application process exits
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-exited:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if !waitForProbes(ctx, probes) {
 		return
 	}
+
+	st.markReady()
+	r.view.Writef("✅  Local app '%s' is ready\n", application.Name)
+}
+
+// GetStatus returns the current status of a local application from its name
+func (r *runner) GetStatus(name string) (Status, bool) {
+	r.mux.Lock()
+	st, ok := r.states[name]
+	r.mux.Unlock()
+
+	if !ok {
+		return Status{}, false
+	}
+
+	return st.getStatus(), true
+}
+
+func (r *runner) newState(name string) *state {
+	st := newState()
+
+	r.mux.Lock()
+	r.states[name] = st
+	r.mux.Unlock()
+
+	return st
 }
 
 // Restart kills the current application launch (if it exists) and launch a new one
@@ -108,29 +312,86 @@ func (r *runner) Restart(application *config.Application) {
 
 // Stop stops all the currently active local applications
 func (r *runner) Stop() error {
+	var wg sync.WaitGroup
+
 	for _, application := range r.applications {
-		r.stopApplication(application)
+		wg.Add(1)
+
+		go func(application *config.Application) {
+			defer wg.Done()
+			r.stopApplication(application)
+		}(application)
 	}
 
+	wg.Wait()
+
 	return nil
 }
 
+// stopApplication gracefully stops an application: stop commands are run first so they can stop
+// what the application has launched (a container for instance), then the stop signal is sent to the
+// process group if it is still running, and finally it is killed if it did not stop in time.
 func (r *runner) stopApplication(application *config.Application) {
-	if cmd, ok := r.cmds[application.Name]; ok {
-		pgid, err := syscall.Getpgid(cmd.Process.Pid)
-		if err == nil {
-			syscall.Kill(-pgid, syscall.SIGKILL)
-			cmd.Wait()
-		}
+	r.mux.Lock()
+	cmd, ok := r.cmds[application.Name]
+	st, hasState := r.states[application.Name]
+	r.mux.Unlock()
+
+	// Ensure the application will not be restarted by its restart policy
+	if hasState {
+		st.markStopping()
 	}
 
 	// In case we have stop command, run it
-	if len(application.Run.StopCommands) > 0 {
+	if application.Run != nil && len(application.Run.StopCommands) > 0 {
 		cmd := helper.BuildCmd(application.Run.StopCommands, application.GetPath(), nil, nil)
 		if err := cmd.Run(); err != nil {
 			r.view.Writef("❌  Cannot run stop command for application '%s': %v\n", application.Name, err)
 		}
+	}
+
+	if ok && hasState && cmd.Process != nil {
+		r.terminate(application, cmd, st.getExitedChannel())
+	}
+}
+
+func (r *runner) terminate(application *config.Application, cmd *exec.Cmd, exited <-chan struct{}) {
+	select {
+	case <-exited:
+		return
+	default:
+	}
+
+	pgid, err := syscall.Getpgid(cmd.Process.Pid)
+	if err != nil {
+		return
+	}
 
-		cmd.Wait()
+	signal, err := application.Run.GetStopSignal()
+	if err != nil {
+		signal = syscall.SIGTERM
 	}
+
+	timeout := application.Run.GetStopTimeout()
+
+	r.view.Writef("🛑  Stopping local app '%s' (%s, timeout: %s)...\n", application.Name, getSignalName(signal), timeout)
+	syscall.Kill(-pgid, signal)
+
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		r.view.Writef("⚠️  Local app '%s' did not stop within %s, killing it\n", application.Name, timeout)
+		syscall.Kill(-pgid, syscall.SIGKILL)
+		<-exited
+	}
+}
+
+func getSignalName(signal syscall.Signal) string {
+	for name, value := range config.AvailableStopSignals {
+		if value == signal {
+			return name
+		}
+	}
+
+	return signal.String()
 }
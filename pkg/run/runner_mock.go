@@ -39,6 +39,21 @@ func (m *MockRunner) EXPECT() *MockRunnerMockRecorder {
 	return m.recorder
 }
 
+// GetStatus mocks base method.
+func (m *MockRunner) GetStatus(name string) (Status, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus", name)
+	ret0, _ := ret[0].(Status)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetStatus indicates an expected call of GetStatus.
+func (mr *MockRunnerMockRecorder) GetStatus(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockRunner)(nil).GetStatus), name)
+}
+
 // Restart mocks base method.
 func (m *MockRunner) Restart(application *config.Application) {
 	m.ctrl.T.Helper()
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/eko/monday/internal/wait"
 	"github.com/eko/monday/pkg/config"
 	"github.com/eko/monday/pkg/log"
 	"github.com/eko/monday/pkg/proxy"
@@ -72,6 +73,233 @@ func TestRunAll(t *testing.T) {
 	}
 }
 
+func TestRunAllWithDependencies(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⏳  Local app '%s' is waiting for its dependencies: %s\n", "test-api", "test-db")
+	gomock.InOrder(
+		view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-db", "/"),
+		view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-api", "/"),
+	)
+	view.EXPECT().Write(log.ColorGreen + "test-db" + log.ColorWhite + " db\n")
+	view.EXPECT().Write(log.ColorGreen + "test-api" + log.ColorWhite + " api\n")
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	project := &config.Project{
+		Name: "My project name",
+		Applications: []*config.Application{
+			{
+				Name:      "test-api",
+				Path:      "/",
+				DependsOn: []string{"test-db"},
+				Run:       &config.Run{Command: "echo api"},
+			},
+			{
+				Name: "test-db",
+				Path: "/",
+				Run:  &config.Run{Command: "echo db"},
+			},
+		},
+	}
+
+	runner := NewRunner(view, proxyfier, project, &config.GlobalRun{})
+
+	// When
+	runner.RunAll()
+
+	// Then
+	waitForStateDone(t, runner, "test-db")
+	waitForStateDone(t, runner, "test-api")
+}
+
+func TestRunAllWhenDependencyStopped(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⏳  Local app '%s' is waiting for its dependencies: %s\n", "test-api", "test-db")
+	view.EXPECT().Writef("❌  Please declare a 'run' section for application %s\n", "test-db")
+	view.EXPECT().Writef("❌  Local app '%s' will not be launched because its dependency '%s' has stopped\n", "test-api", "test-db")
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	project := &config.Project{
+		Name: "My project name",
+		Applications: []*config.Application{
+			{
+				Name:      "test-api",
+				Path:      "/",
+				DependsOn: []string{"test-db"},
+				Run:       &config.Run{Command: "echo api"},
+			},
+			{
+				Name: "test-db",
+				Path: "/",
+			},
+		},
+	}
+
+	runner := NewRunner(view, proxyfier, project, &config.GlobalRun{})
+
+	// When
+	runner.RunAll()
+
+	// Then
+	waitForStateDone(t, runner, "test-api")
+
+	_, ok := runner.cmds["test-api"]
+	assert.False(t, ok)
+}
+
+func TestRunAllWithReadiness(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⏳  Local app '%s' is waiting for its dependencies: %s\n", "test-api", "test-db")
+	gomock.InOrder(
+		view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-db", "/"),
+		view.EXPECT().Writef("⏳  Waiting for local app '%s' to be ready (%s)...\n", "test-db", "log /accepting connections/"),
+		view.EXPECT().Write(log.ColorGreen+"test-db"+log.ColorWhite+" accepting connections\n"),
+		view.EXPECT().Writef("✅  Local app '%s' is ready\n", "test-db"),
+		view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-api", "/"),
+	)
+	view.EXPECT().Write(log.ColorGreen + "test-api" + log.ColorWhite + " api\n")
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	project := &config.Project{
+		Name: "My project name",
+		Applications: []*config.Application{
+			{
+				Name:      "test-api",
+				Path:      "/",
+				DependsOn: []string{"test-db"},
+				Run:       &config.Run{Command: "echo api"},
+			},
+			{
+				Name: "test-db",
+				Path: "/",
+				Readiness: &config.Readiness{
+					Log: &config.LogProbe{
+						ProbeSettings: config.ProbeSettings{Interval: 10 * time.Millisecond},
+						Pattern:       "accepting connections",
+					},
+				},
+				Run: &config.Run{Command: "sleep 0.2; echo accepting connections; sleep 0.5"},
+			},
+		},
+	}
+
+	runner := NewRunner(view, proxyfier, project, &config.GlobalRun{})
+
+	// When
+	runner.RunAll()
+
+	// Then
+	waitForStateDone(t, runner, "test-api")
+
+	status, ok := runner.GetStatus("test-db")
+	assert.True(t, ok)
+	assert.Equal(t, StateReady, status.State)
+
+	waitForStateDone(t, runner, "test-db")
+
+	status, _ = runner.GetStatus("test-db")
+	assert.Equal(t, StateStopped, status.State)
+}
+
+func TestRunWithRestartPolicy(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	original := newRestartBackoff
+	newRestartBackoff = func() *wait.Backoff {
+		return &wait.Backoff{Min: 10 * time.Millisecond, Max: 10 * time.Millisecond}
+	}
+	t.Cleanup(func() { newRestartBackoff = original })
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-app", "/").Times(3)
+	view.EXPECT().Writef("❌  Cannot run the application %s on path %s: %v\n", "test-app", "/", gomock.Any()).Times(3)
+	view.EXPECT().Writef("🔁  Local app '%s' exited with code %d, restarting in %s (restart #%d)...\n", "test-app", 3, 10*time.Millisecond, 1)
+	view.EXPECT().Writef("🔁  Local app '%s' exited with code %d, restarting in %s (restart #%d)...\n", "test-app", 3, 10*time.Millisecond, 2)
+	view.EXPECT().Writef("💥  Local app '%s' is crash-looping, giving up after %d restarts (exit code: %d)\n", "test-app", 2, 3)
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	project := &config.Project{
+		Name: "My project name",
+		Applications: []*config.Application{
+			{
+				Name: "test-app",
+				Path: "/",
+				Run: &config.Run{
+					Command:    "exit 3",
+					Restart:    config.RestartOnFailure,
+					MaxRetries: 2,
+				},
+			},
+		},
+	}
+
+	runner := NewRunner(view, proxyfier, project, &config.GlobalRun{})
+
+	// When
+	runner.RunAll()
+
+	// Then
+	waitForStateDone(t, runner, "test-app")
+
+	status, ok := runner.GetStatus("test-app")
+	assert.True(t, ok)
+	assert.Equal(t, Status{State: StateFailed, ExitCode: 3, Restarts: 2}, status)
+}
+
+func TestRunWithRestartPolicyWhenSuccessful(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-app", "/")
+	view.EXPECT().Write(log.ColorGreen + "test-app" + log.ColorWhite + " done\n")
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	project := &config.Project{
+		Name: "My project name",
+		Applications: []*config.Application{
+			{
+				Name: "test-app",
+				Path: "/",
+				Run: &config.Run{
+					Command: "echo done",
+					Restart: config.RestartOnFailure,
+				},
+			},
+		},
+	}
+
+	runner := NewRunner(view, proxyfier, project, &config.GlobalRun{})
+
+	// When
+	runner.RunAll()
+
+	// Then
+	waitForStateDone(t, runner, "test-app")
+
+	status, _ := runner.GetStatus("test-app")
+	assert.Equal(t, Status{State: StateStopped, ExitCode: 0, Restarts: 0}, status)
+}
+
 func TestStop(t *testing.T) {
 	// Given
 	ctrl := gomock.NewController(t)
@@ -111,6 +339,144 @@ func TestStop(t *testing.T) {
 	}
 }
 
+func waitForStateDone(t *testing.T, runner *runner, name string) {
+	runner.mux.Lock()
+	st, ok := runner.states[name]
+	runner.mux.Unlock()
+
+	if !ok {
+		t.Fatalf("Cannot retrieve state of application '%s'", name)
+	}
+
+	select {
+	case <-st.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Application '%s' has not stopped in time", name)
+	}
+}
+
+func TestStopGracefully(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⏳  Waiting for local app '%s' to be ready (%s)...\n", "test-app", "log /started/")
+	gomock.InOrder(
+		view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-app", "/"),
+		view.EXPECT().Write(log.ColorGreen+"test-app"+log.ColorWhite+" started\n"),
+		view.EXPECT().Writef("✅  Local app '%s' is ready\n", "test-app"),
+		view.EXPECT().Writef("🛑  Stopping local app '%s' (%s, timeout: %s)...\n", "test-app", "SIGINT", 5*time.Second),
+		view.EXPECT().Write(log.ColorGreen+"test-app"+log.ColorWhite+" graceful\n"),
+	)
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	project := &config.Project{
+		Name: "My project name",
+		Applications: []*config.Application{
+			{
+				Name: "test-app",
+				Path: "/",
+				Readiness: &config.Readiness{
+					Log: &config.LogProbe{
+						ProbeSettings: config.ProbeSettings{Interval: 10 * time.Millisecond},
+						Pattern:       "started",
+					},
+				},
+				Run: &config.Run{
+					Command:      "trap \"echo graceful; exit 0\" INT; echo started; while true; do sleep 0.1; done",
+					StopCommands: []string{"true"},
+					StopSignal:   "SIGINT",
+					StopTimeout:  5 * time.Second,
+				},
+			},
+		},
+	}
+
+	runner := NewRunner(view, proxyfier, project, &config.GlobalRun{})
+	runner.RunAll()
+
+	waitForStateReady(t, runner, "test-app")
+
+	// When
+	runner.Stop()
+
+	// Then
+	waitForStateDone(t, runner, "test-app")
+
+	status, _ := runner.GetStatus("test-app")
+	assert.Equal(t, Status{State: StateStopped, ExitCode: 0}, status)
+}
+
+func TestStopWhenTimeout(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("⏳  Waiting for local app '%s' to be ready (%s)...\n", "test-app", "log /started/")
+	gomock.InOrder(
+		view.EXPECT().Writef("🏁  Running local app '%s' (%s)...\n", "test-app", "/"),
+		view.EXPECT().Write(log.ColorGreen+"test-app"+log.ColorWhite+" started\n"),
+		view.EXPECT().Writef("✅  Local app '%s' is ready\n", "test-app"),
+		view.EXPECT().Writef("🛑  Stopping local app '%s' (%s, timeout: %s)...\n", "test-app", "SIGTERM", 200*time.Millisecond),
+		view.EXPECT().Writef("⚠️  Local app '%s' did not stop within %s, killing it\n", "test-app", 200*time.Millisecond),
+	)
+
+	proxyfier := proxy.NewMockProxy(ctrl)
+
+	project := &config.Project{
+		Name: "My project name",
+		Applications: []*config.Application{
+			{
+				Name: "test-app",
+				Path: "/",
+				Readiness: &config.Readiness{
+					Log: &config.LogProbe{
+						ProbeSettings: config.ProbeSettings{Interval: 10 * time.Millisecond},
+						Pattern:       "started",
+					},
+				},
+				Run: &config.Run{
+					Command:     "trap \"\" TERM; echo started; while true; do sleep 0.1; done",
+					StopTimeout: 200 * time.Millisecond,
+				},
+			},
+		},
+	}
+
+	runner := NewRunner(view, proxyfier, project, &config.GlobalRun{})
+	runner.RunAll()
+
+	waitForStateReady(t, runner, "test-app")
+
+	// When
+	runner.Stop()
+
+	// Then
+	waitForStateDone(t, runner, "test-app")
+
+	status, _ := runner.GetStatus("test-app")
+	assert.Equal(t, Status{State: StateStopped, ExitCode: -1}, status)
+}
+
+func waitForStateReady(t *testing.T, runner *runner, name string) {
+	runner.mux.Lock()
+	st, ok := runner.states[name]
+	runner.mux.Unlock()
+
+	if !ok {
+		t.Fatalf("Cannot retrieve state of application '%s'", name)
+	}
+
+	select {
+	case <-st.ready:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Application '%s' is not ready in time", name)
+	}
+}
+
 func getMockedProjectWithApplication() *config.Project {
 	return &config.Project{
 		Name: "My project name",
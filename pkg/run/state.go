@@ -0,0 +1,150 @@
+package run
+
+import "sync"
+
+const (
+	// StatePending is the state of an application waiting to be launched
+	StatePending = "pending"
+	// StateStarting is the state of a launched application whose readiness probes did not succeed yet
+	StateStarting = "starting"
+	// StateReady is the state of a launched application that is ready to serve traffic
+	StateReady = "ready"
+	// StateCrashLoopBackOff is the state of an exited application waiting to be restarted
+	StateCrashLoopBackOff = "crash-loop-backoff"
+	// StateFailed is the state of an application that exited too many times to be restarted again
+	StateFailed = "failed"
+	// StateStopped is the state of an application that is not running anymore
+	StateStopped = "stopped"
+)
+
+// Status represents a snapshot of a local application lifecycle
+type Status struct {
+	State    string
+	ExitCode int
+	Restarts int
+}
+
+// state tracks the lifecycle of a launched local application so that other
+// applications depending on it can wait for it to be ready
+type state struct {
+	status       Status
+	statusMux    sync.RWMutex
+	ready        chan struct{}
+	done         chan struct{}
+	stopping     chan struct{}
+	exited       chan struct{}
+	readyOnce    sync.Once
+	doneOnce     sync.Once
+	stoppingOnce sync.Once
+}
+
+func newState() *state {
+	return &state{
+		status:   Status{State: StatePending},
+		ready:    make(chan struct{}),
+		done:     make(chan struct{}),
+		stopping: make(chan struct{}),
+	}
+}
+
+// getStatus returns a copy of the current application status
+func (s *state) getStatus() Status {
+	s.statusMux.RLock()
+	defer s.statusMux.RUnlock()
+
+	return s.status
+}
+
+func (s *state) setState(value string) {
+	s.statusMux.Lock()
+	defer s.statusMux.Unlock()
+
+	s.status.State = value
+}
+
+// setExitedChannel sets the channel closed when the current application process exits
+func (s *state) setExitedChannel(exited chan struct{}) {
+	s.statusMux.Lock()
+	defer s.statusMux.Unlock()
+
+	s.exited = exited
+}
+
+// getExitedChannel returns the channel closed when the current application process exits
+func (s *state) getExitedChannel() chan struct{} {
+	s.statusMux.RLock()
+	defer s.statusMux.RUnlock()
+
+	return s.exited
+}
+
+// markStarting notifies that the application process has been launched
+func (s *state) markStarting() {
+	s.setState(StateStarting)
+}
+
+// markReady notifies that the application is ready to be used by its dependents
+func (s *state) markReady() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	s.setState(StateReady)
+	s.readyOnce.Do(func() {
+		close(s.ready)
+	})
+}
+
+// markExited records the exit code of the latest application process
+func (s *state) markExited(exitCode int) {
+	s.statusMux.Lock()
+	defer s.statusMux.Unlock()
+
+	s.status.ExitCode = exitCode
+}
+
+// markCrashLoopBackOff notifies that the application will be restarted after some delay
+func (s *state) markCrashLoopBackOff() {
+	s.statusMux.Lock()
+	defer s.statusMux.Unlock()
+
+	s.status.State = StateCrashLoopBackOff
+	s.status.Restarts++
+}
+
+// markFailed notifies that the application will not be restarted anymore
+func (s *state) markFailed() {
+	s.setState(StateFailed)
+}
+
+// markStopping notifies that the application is stopped on purpose and must not be restarted
+func (s *state) markStopping() {
+	s.stoppingOnce.Do(func() {
+		close(s.stopping)
+	})
+}
+
+// isStopping indicates if the application is stopped on purpose
+func (s *state) isStopping() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// markDone notifies that the application is not running anymore
+func (s *state) markDone() {
+	s.doneOnce.Do(func() {
+		s.statusMux.Lock()
+		if s.status.State != StateFailed {
+			s.status.State = StateStopped
+		}
+		s.statusMux.Unlock()
+
+		close(s.done)
+	})
+}
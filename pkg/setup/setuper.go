@@ -77,6 +77,12 @@ func (s *setuper) Setup(application *config.Application) {
 		envs = helper.MergeMapString(setup.Env, s.conf.Env)
 	}
 
+	// Values read from the cluster come first so that local ones override them
+	if err := helper.AddEnvVariablesFromCluster(cmd, setup.EnvFrom); err != nil {
+		s.view.Writef("❌  %v\n", err)
+		return
+	}
+
 	helper.AddEnvVariables(cmd, envs)
 	if err := helper.AddEnvVariablesFromFile(cmd, setup.GetEnvFile()); err != nil {
 		s.view.Writef("❌  %v\n", err)
@@ -0,0 +1,295 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eko/monday/pkg/config"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DirectoryName is the name of the directory (in Monday data directory) containing session journals
+	DirectoryName = "state"
+)
+
+var (
+	// Current is the journal of the running session. It does not record anything until a
+	// session journal is opened.
+	Current Journal = &nopJournal{}
+)
+
+// Journal records the side effects made on the host or on remote clusters during a session
+// so that they can be reverted even if Monday has not been stopped properly
+type Journal interface {
+	AddHost(ip, hostname string) error
+	RemoveHost(hostname string) error
+	AddIPAlias(iface, ip string) error
+	RemoveIPAlias(ip string) error
+	AddDeployment(deployment Deployment) error
+	RemoveDeployment(context, namespace, kind, name string) error
+	Close() error
+}
+
+// Session is the content of a session journal file
+type Session struct {
+	PID         int          `json:"pid"`
+	Project     string       `json:"project"`
+	StartedAt   time.Time    `json:"started_at"`
+	Hosts       []Host       `json:"hosts,omitempty"`
+	IPAliases   []IPAlias    `json:"ip_aliases,omitempty"`
+	Deployments []Deployment `json:"deployments,omitempty"`
+}
+
+// IsEmpty indicates if the session does not have any side effect left to revert
+func (s *Session) IsEmpty() bool {
+	return len(s.Hosts) == 0 && len(s.IPAliases) == 0 && len(s.Deployments) == 0
+}
+
+// Host is a hostname entry added into the hosts file
+type Host struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname"`
+}
+
+// IPAlias is an IP address added on a network interface
+type IPAlias struct {
+	Interface string `json:"interface"`
+	IP        string `json:"ip"`
+}
+
+// Deployment is a Kubernetes workload (a deployment unless another kind is specified) that has been
+// updated with the proxy. Image and ports are the ones of the container replaced by the proxy image.
+type Deployment struct {
+	Context   string                `json:"context"`
+	Namespace string                `json:"namespace"`
+	Kind      string                `json:"kind,omitempty"`
+	Name      string                `json:"name"`
+	UID       string                `json:"uid,omitempty"`
+	Container string                `json:"container,omitempty"`
+	Image     string                `json:"image"`
+	Ports     []apiv1.ContainerPort `json:"ports,omitempty"`
+}
+
+// GetDirectory returns the directory containing session journals
+func GetDirectory() string {
+	return filepath.Join(config.GetDataPath(), DirectoryName)
+}
+
+type journal struct {
+	path    string
+	session *Session
+	mux     sync.Mutex
+}
+
+// NewJournal creates a new journal file for the current process in the given directory
+func NewJournal(directory, project string) (*journal, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, fmt.Errorf("Unable to create state directory '%s': %v", directory, err)
+	}
+
+	pid := os.Getpid()
+
+	j := &journal{
+		path: getSessionPath(directory, pid),
+		session: &Session{
+			PID:       pid,
+			Project:   project,
+			StartedAt: time.Now(),
+		},
+	}
+
+	if err := j.save(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// AddHost records a hostname added into the hosts file
+func (j *journal) AddHost(ip, hostname string) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	for _, host := range j.session.Hosts {
+		if host.Hostname == hostname {
+			return nil
+		}
+	}
+
+	j.session.Hosts = append(j.session.Hosts, Host{IP: ip, Hostname: hostname})
+
+	return j.save()
+}
+
+// RemoveHost records that a hostname has been removed from the hosts file
+func (j *journal) RemoveHost(hostname string) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	hosts := make([]Host, 0, len(j.session.Hosts))
+	for _, host := range j.session.Hosts {
+		if host.Hostname != hostname {
+			hosts = append(hosts, host)
+		}
+	}
+
+	j.session.Hosts = hosts
+
+	return j.save()
+}
+
+// AddIPAlias records an IP address added on a network interface
+func (j *journal) AddIPAlias(iface, ip string) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	for _, alias := range j.session.IPAliases {
+		if alias.IP == ip {
+			return nil
+		}
+	}
+
+	j.session.IPAliases = append(j.session.IPAliases, IPAlias{Interface: iface, IP: ip})
+
+	return j.save()
+}
+
+// RemoveIPAlias records that an IP address has been removed from its network interface
+func (j *journal) RemoveIPAlias(ip string) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	aliases := make([]IPAlias, 0, len(j.session.IPAliases))
+	for _, alias := range j.session.IPAliases {
+		if alias.IP != ip {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	j.session.IPAliases = aliases
+
+	return j.save()
+}
+
+// AddDeployment records a deployment that has been updated with the proxy image
+func (j *journal) AddDeployment(deployment Deployment) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	for _, d := range j.session.Deployments {
+		if d.Context == deployment.Context && d.Namespace == deployment.Namespace && d.Kind == deployment.Kind && d.Name == deployment.Name {
+			return nil
+		}
+	}
+
+	j.session.Deployments = append(j.session.Deployments, deployment)
+
+	return j.save()
+}
+
+// RemoveDeployment records that a deployment (or any other kind of workload) has been restored
+func (j *journal) RemoveDeployment(context, namespace, kind, name string) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	deployments := make([]Deployment, 0, len(j.session.Deployments))
+	for _, d := range j.session.Deployments {
+		if d.Context != context || d.Namespace != namespace || d.Kind != kind || d.Name != name {
+			deployments = append(deployments, d)
+		}
+	}
+
+	j.session.Deployments = deployments
+
+	return j.save()
+}
+
+// Close removes the journal file in case every side effect has been reverted. Otherwise, the
+// file is kept so that remaining side effects can be reverted by a cleanup.
+func (j *journal) Close() error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	if !j.session.IsEmpty() {
+		return nil
+	}
+
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// save atomically writes the session into the journal file so that a crash
+// never leaves a partially written file
+func (j *journal) save() error {
+	return writeSession(j.path, j.session)
+}
+
+func getSessionPath(directory string, pid int) string {
+	return filepath.Join(directory, fmt.Sprintf("%d.json", pid))
+}
+
+func writeSession(path string, session *Session) error {
+	content, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".session-*")
+	if err != nil {
+		return fmt.Errorf("Unable to write state file '%s': %v", path, err)
+	}
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("Unable to write state file '%s': %v", path, err)
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("Unable to write state file '%s': %v", path, err)
+	}
+
+	file.Close()
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("Unable to write state file '%s': %v", path, err)
+	}
+
+	return nil
+}
+
+func readSession(path string) (*Session, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{}
+	if err := json.Unmarshal(content, session); err != nil {
+		return nil, fmt.Errorf("Unable to read state file '%s': %v", path, err)
+	}
+
+	return session, nil
+}
+
+// nopJournal is used when no session journal is opened
+type nopJournal struct{}
+
+func (j *nopJournal) AddHost(ip, hostname string) error                            { return nil }
+func (j *nopJournal) RemoveHost(hostname string) error                             { return nil }
+func (j *nopJournal) AddIPAlias(iface, ip string) error                            { return nil }
+func (j *nopJournal) RemoveIPAlias(ip string) error                                { return nil }
+func (j *nopJournal) AddDeployment(deployment Deployment) error                    { return nil }
+func (j *nopJournal) RemoveDeployment(context, namespace, kind, name string) error { return nil }
+func (j *nopJournal) Close() error                                                 { return nil }
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJournal(t *testing.T) {
+	// Given
+	directory := t.TempDir() + "/state"
+
+	// When
+	j, err := NewJournal(directory, "my-project")
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, getSessionPath(directory, os.Getpid()), j.path)
+
+	session, err := readSession(j.path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.Getpid(), session.PID)
+	assert.Equal(t, "my-project", session.Project)
+	assert.True(t, session.IsEmpty())
+}
+
+func TestJournalRecordsSideEffects(t *testing.T) {
+	// Given
+	j, err := NewJournal(t.TempDir(), "my-project")
+	assert.Nil(t, err)
+
+	// When
+	assert.Nil(t, j.AddHost("127.0.1.1", "my-app.svc.local"))
+	assert.Nil(t, j.AddHost("::127:0:1:1", "my-app.svc.local"))
+	assert.Nil(t, j.AddIPAlias("lo", "127.0.1.1"))
+	assert.Nil(t, j.AddDeployment(Deployment{Context: "ctx", Namespace: "backend", Name: "my-app", Image: "acme.tld/my-app"}))
+
+	// Then
+	session, err := readSession(j.path)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []Host{{IP: "127.0.1.1", Hostname: "my-app.svc.local"}}, session.Hosts)
+	assert.Equal(t, []IPAlias{{Interface: "lo", IP: "127.0.1.1"}}, session.IPAliases)
+	assert.Len(t, session.Deployments, 1)
+	assert.Equal(t, "acme.tld/my-app", session.Deployments[0].Image)
+}
+
+func TestJournalDeploymentsWhenSameNameWithDifferentKinds(t *testing.T) {
+	// Given
+	j, err := NewJournal(t.TempDir(), "my-project")
+	assert.Nil(t, err)
+
+	assert.Nil(t, j.AddDeployment(Deployment{Context: "ctx", Namespace: "backend", Kind: "deployment", Name: "my-app"}))
+	assert.Nil(t, j.AddDeployment(Deployment{Context: "ctx", Namespace: "backend", Kind: "statefulset", Name: "my-app"}))
+
+	// When
+	err = j.RemoveDeployment("ctx", "backend", "deployment", "my-app")
+
+	// Then
+	assert.Nil(t, err)
+
+	session, err := readSession(j.path)
+	assert.Nil(t, err)
+	assert.Len(t, session.Deployments, 1)
+	assert.Equal(t, "statefulset", session.Deployments[0].Kind)
+}
+
+func TestJournalCloseWhenEverythingIsReverted(t *testing.T) {
+	// Given
+	j, err := NewJournal(t.TempDir(), "my-project")
+	assert.Nil(t, err)
+
+	assert.Nil(t, j.AddHost("127.0.1.1", "my-app.svc.local"))
+	assert.Nil(t, j.AddIPAlias("lo", "127.0.1.1"))
+	assert.Nil(t, j.AddDeployment(Deployment{Context: "ctx", Namespace: "backend", Kind: "deployment", Name: "my-app"}))
+
+	assert.Nil(t, j.RemoveHost("my-app.svc.local"))
+	assert.Nil(t, j.RemoveIPAlias("127.0.1.1"))
+	assert.Nil(t, j.RemoveDeployment("ctx", "backend", "deployment", "my-app"))
+
+	// When
+	err = j.Close()
+
+	// Then
+	assert.Nil(t, err)
+
+	_, err = os.Stat(j.path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJournalCloseWhenSideEffectsRemain(t *testing.T) {
+	// Given
+	j, err := NewJournal(t.TempDir(), "my-project")
+	assert.Nil(t, err)
+
+	assert.Nil(t, j.AddHost("127.0.1.1", "my-app.svc.local"))
+
+	// When
+	err = j.Close()
+
+	// Then
+	assert.Nil(t, err)
+
+	session, err := readSession(j.path)
+	assert.Nil(t, err)
+	assert.Len(t, session.Hosts, 1)
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/eko/monday/pkg/ui"
+)
+
+// Reverter reverts the side effects recorded in a session journal
+type Reverter interface {
+	RemoveHost(hostname string) error
+	RemoveIPAlias(iface, ip string) error
+	RestoreDeployment(ctx context.Context, deployment Deployment) error
+}
+
+// StaleSession is a session journal left by a Monday process which is not running anymore
+type StaleSession struct {
+	Path    string
+	Session *Session
+}
+
+// FindStaleSessions returns the session journals of the given directory whose process is not running anymore
+func FindStaleSessions(directory string) ([]*StaleSession, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*StaleSession, 0)
+
+	for _, path := range paths {
+		session, err := readSession(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// A journal holding the current pid has been left by a previous process (pid reuse)
+		// as the current session journal is only created after recovery
+		if session.PID != os.Getpid() && isProcessRunning(session.PID) {
+			continue
+		}
+
+		sessions = append(sessions, &StaleSession{Path: path, Session: session})
+	}
+
+	return sessions, nil
+}
+
+// Recover reverts the side effects left by stale sessions of the given directory. Reverting is
+// idempotent: side effects that could not be reverted are kept in the journal so that
+// a next recovery can try again. It returns the number of stale sessions found.
+func Recover(ctx context.Context, directory string, reverter Reverter, view ui.View) (int, error) {
+	sessions, err := FindStaleSessions(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, stale := range sessions {
+		session := stale.Session
+
+		view.Writef("🧹  Cleaning up stale session of project '%s' (pid: %d, started at %s)...\n", session.Project, session.PID, session.StartedAt.Format("2006-01-02 15:04:05"))
+
+		remaining := &Session{
+			PID:       session.PID,
+			Project:   session.Project,
+			StartedAt: session.StartedAt,
+		}
+
+		for _, deployment := range session.Deployments {
+			if err := reverter.RestoreDeployment(ctx, deployment); err != nil {
+				view.Writef("❌  Unable to restore deployment '%s' (context: %s, namespace: %s): %v\n", deployment.Name, deployment.Context, deployment.Namespace, err)
+				remaining.Deployments = append(remaining.Deployments, deployment)
+				continue
+			}
+
+			view.Writef("✅  Deployment '%s' has been restored\n", deployment.Name)
+		}
+
+		for _, host := range session.Hosts {
+			if err := reverter.RemoveHost(host.Hostname); err != nil {
+				view.Writef("❌  Unable to remove host '%s' from hosts file: %v\n", host.Hostname, err)
+				remaining.Hosts = append(remaining.Hosts, host)
+				continue
+			}
+
+			view.Writef("✅  Host '%s' has been removed from hosts file\n", host.Hostname)
+		}
+
+		for _, alias := range session.IPAliases {
+			if err := reverter.RemoveIPAlias(alias.Interface, alias.IP); err != nil {
+				view.Writef("❌  Unable to remove IP address '%s' from network interface '%s': %v\n", alias.IP, alias.Interface, err)
+				remaining.IPAliases = append(remaining.IPAliases, alias)
+				continue
+			}
+
+			view.Writef("✅  IP address '%s' has been removed from network interface '%s'\n", alias.IP, alias.Interface)
+		}
+
+		if !remaining.IsEmpty() {
+			if err := writeSession(stale.Path, remaining); err != nil {
+				return len(sessions), err
+			}
+
+			continue
+		}
+
+		if err := os.Remove(stale.Path); err != nil && !os.IsNotExist(err) {
+			return len(sessions), err
+		}
+	}
+
+	return len(sessions), nil
+}
+
+// isProcessRunning checks if a process with the given pid still exists
+func isProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	err := syscall.Kill(pid, syscall.Signal(0))
+
+	// EPERM means the process exists but is owned by another user
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pkg/state/recover.go
+//
+// Generated by this command:
+//
+//	mockgen -source=pkg/state/recover.go -destination=pkg/state/recover_mock.go -package=state
+//
+
+// Package state is a generated GoMock package.
+package state
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReverter is a mock of Reverter interface.
+type MockReverter struct {
+	ctrl     *gomock.Controller
+	recorder *MockReverterMockRecorder
+}
+
+// MockReverterMockRecorder is the mock recorder for MockReverter.
+type MockReverterMockRecorder struct {
+	mock *MockReverter
+}
+
+// NewMockReverter creates a new mock instance.
+func NewMockReverter(ctrl *gomock.Controller) *MockReverter {
+	mock := &MockReverter{ctrl: ctrl}
+	mock.recorder = &MockReverterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReverter) EXPECT() *MockReverterMockRecorder {
+	return m.recorder
+}
+
+// RemoveHost mocks base method.
+func (m *MockReverter) RemoveHost(hostname string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveHost", hostname)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveHost indicates an expected call of RemoveHost.
+func (mr *MockReverterMockRecorder) RemoveHost(hostname any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveHost", reflect.TypeOf((*MockReverter)(nil).RemoveHost), hostname)
+}
+
+// RemoveIPAlias mocks base method.
+func (m *MockReverter) RemoveIPAlias(iface, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveIPAlias", iface, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveIPAlias indicates an expected call of RemoveIPAlias.
+func (mr *MockReverterMockRecorder) RemoveIPAlias(iface, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveIPAlias", reflect.TypeOf((*MockReverter)(nil).RemoveIPAlias), iface, ip)
+}
+
+// RestoreDeployment mocks base method.
+func (m *MockReverter) RestoreDeployment(ctx context.Context, deployment Deployment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreDeployment", ctx, deployment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreDeployment indicates an expected call of RestoreDeployment.
+func (mr *MockReverterMockRecorder) RestoreDeployment(ctx, deployment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreDeployment", reflect.TypeOf((*MockReverter)(nil).RestoreDeployment), ctx, deployment)
+}
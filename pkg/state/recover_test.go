@@ -0,0 +1,113 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eko/monday/pkg/ui"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// stalePID is a pid that cannot be used by a running process
+const stalePID = 1 << 30
+
+func TestFindStaleSessions(t *testing.T) {
+	// Given
+	directory := t.TempDir()
+
+	assert.Nil(t, writeSession(getSessionPath(directory, stalePID), &Session{PID: stalePID, Project: "stale"}))
+
+	// Parent process (go test) is still running
+	assert.Nil(t, writeSession(getSessionPath(directory, os.Getppid()), &Session{PID: os.Getppid(), Project: "running"}))
+
+	// When
+	sessions, err := FindStaleSessions(directory)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "stale", sessions[0].Session.Project)
+}
+
+func TestRecover(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	directory := t.TempDir()
+	path := getSessionPath(directory, stalePID)
+
+	deployment := Deployment{Context: "ctx", Namespace: "backend", Name: "my-app", Image: "acme.tld/my-app"}
+
+	assert.Nil(t, writeSession(path, &Session{
+		PID:         stalePID,
+		Project:     "my-project",
+		StartedAt:   time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC),
+		Hosts:       []Host{{IP: "127.0.1.1", Hostname: "my-app.svc.local"}},
+		IPAliases:   []IPAlias{{Interface: "lo", IP: "127.0.1.1"}},
+		Deployments: []Deployment{deployment},
+	}))
+
+	reverter := NewMockReverter(ctrl)
+	reverter.EXPECT().RestoreDeployment(ctx, deployment).Return(nil)
+	reverter.EXPECT().RemoveHost("my-app.svc.local").Return(nil)
+	reverter.EXPECT().RemoveIPAlias("lo", "127.0.1.1").Return(nil)
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef("🧹  Cleaning up stale session of project '%s' (pid: %d, started at %s)...\n", "my-project", stalePID, "2020-01-01 10:00:00")
+	view.EXPECT().Writef("✅  Deployment '%s' has been restored\n", "my-app")
+	view.EXPECT().Writef("✅  Host '%s' has been removed from hosts file\n", "my-app.svc.local")
+	view.EXPECT().Writef("✅  IP address '%s' has been removed from network interface '%s'\n", "127.0.1.1", "lo")
+
+	// When
+	count, err := Recover(ctx, directory, reverter, view)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRecoverWhenRevertFails(t *testing.T) {
+	// Given
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	directory := t.TempDir()
+	path := getSessionPath(directory, stalePID)
+
+	assert.Nil(t, writeSession(path, &Session{
+		PID:     stalePID,
+		Project: "my-project",
+		Hosts: []Host{
+			{IP: "127.0.1.1", Hostname: "my-app.svc.local"},
+			{IP: "127.0.1.2", Hostname: "my-other-app.svc.local"},
+		},
+	}))
+
+	reverter := NewMockReverter(ctrl)
+	reverter.EXPECT().RemoveHost("my-app.svc.local").Return(errors.New("permission denied"))
+	reverter.EXPECT().RemoveHost("my-other-app.svc.local").Return(nil)
+
+	view := ui.NewMockView(ctrl)
+	view.EXPECT().Writef(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// When
+	count, err := Recover(ctx, directory, reverter, view)
+
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	session, err := readSession(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []Host{{IP: "127.0.1.1", Hostname: "my-app.svc.local"}}, session.Hosts)
+}
@@ -17,7 +17,10 @@ type Layout struct {
 	logsView       *view
 	forwardsView   *view
 	proxyView      *view
+	inspectorView  *view
 	viewsOrder     map[string]*view
+
+	inspectorEnabled bool
 }
 
 // NewLayout returns a new layout instance
@@ -38,6 +41,11 @@ func NewLayout(uiEnabled bool) *Layout {
 	return layout
 }
 
+// SetInspectorEnabled adds the HTTP inspector pane to the layout, it has to be called before Init
+func (l *Layout) SetInspectorEnabled(enabled bool) {
+	l.inspectorEnabled = enabled
+}
+
 // Init initializes the gui layout
 func (l *Layout) Init() {
 	if !l.uiEnabled {
@@ -46,6 +54,7 @@ func (l *Layout) Init() {
 		l.logsView = NewEmptyView("logs")
 		l.forwardsView = NewEmptyView("forwards")
 		l.proxyView = NewEmptyView("proxy")
+		l.inspectorView = NewEmptyView("inspector")
 
 		return
 	}
@@ -73,13 +82,19 @@ func (l *Layout) Init() {
 	l.logsView = logsView
 	l.logsView.GetView().Title = fmt.Sprintf("%s (Current)", l.logsView.GetTitle())
 
-	forwardsView, err := l.setView("forwards", " Forwards ", 0, (maxY/2)+10, (maxX/2)-1, maxY-1)
+	// Bottom panes share the width, the inspector one is only shown when HTTP exchanges are captured
+	panes := 2
+	if l.inspectorEnabled {
+		panes = 3
+	}
+
+	forwardsView, err := l.setView("forwards", " Forwards ", 0, (maxY/2)+10, (maxX/panes)-1, maxY-1)
 	if err != nil {
 		panic(err)
 	}
 	l.forwardsView = forwardsView
 
-	proxyView, err := l.setView("proxy", " Proxy ", maxX/2, (maxY/2)+10, maxX-1, maxY-1)
+	proxyView, err := l.setView("proxy", " Proxy ", maxX/panes, (maxY/2)+10, (2*maxX/panes)-1, maxY-1)
 	if err != nil {
 		panic(err)
 	}
@@ -91,6 +106,19 @@ func (l *Layout) Init() {
 		proxyView.GetName():    logsView,
 	}
 
+	if l.inspectorEnabled {
+		inspectorView, err := l.setView("inspector", " Inspector ", 2*maxX/panes, (maxY/2)+10, maxX-1, maxY-1)
+		if err != nil {
+			panic(err)
+		}
+		l.inspectorView = inspectorView
+
+		l.viewsOrder[proxyView.GetName()] = inspectorView
+		l.viewsOrder[inspectorView.GetName()] = logsView
+	} else {
+		l.inspectorView = NewEmptyView("inspector")
+	}
+
 	l.highlighted = logsView
 	l.setKeyBindings()
 
@@ -130,6 +158,11 @@ func (l *Layout) GetProxyView() *view {
 	return l.proxyView
 }
 
+// GetInspectorView returns the HTTP inspector view structure
+func (l *Layout) GetInspectorView() *view {
+	return l.inspectorView
+}
+
 func (l *Layout) setView(name, title string, xx, xy, yx, yy int) (*view, error) {
 	view, err := l.gui.SetView(name, xx, xy, yx, yy)
 	if err != nil && err != gocui.ErrUnknownView {
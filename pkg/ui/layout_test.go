@@ -34,6 +34,38 @@ func TestInit(t *testing.T) {
 	assert.IsType(t, new(view), layout.logsView)
 	assert.IsType(t, new(view), layout.forwardsView)
 	assert.IsType(t, new(view), layout.proxyView)
+	assert.IsType(t, new(view), layout.inspectorView)
+}
+
+func TestInitWhenInspectorEnabled(t *testing.T) {
+	// Given
+	layout := NewLayout(true)
+	layout.gui.Close()
+
+	layout.SetInspectorEnabled(true)
+
+	// When
+	layout.Init()
+
+	// Then
+	assert.NotNil(t, layout.inspectorView.GetView())
+	assert.Len(t, layout.viewsOrder, 4)
+	assert.Equal(t, layout.inspectorView, layout.viewsOrder["proxy"])
+	assert.Equal(t, layout.logsView, layout.viewsOrder["inspector"])
+}
+
+func TestInitWhenInspectorNotEnabled(t *testing.T) {
+	// Given
+	layout := NewLayout(true)
+	layout.gui.Close()
+
+	// When
+	layout.Init()
+
+	// Then
+	assert.Nil(t, layout.inspectorView.GetView())
+	assert.Len(t, layout.viewsOrder, 3)
+	assert.Equal(t, layout.logsView, layout.viewsOrder["proxy"])
 }
 
 func TestTestInitWhenUINotEnabled(t *testing.T) {
@@ -51,12 +83,14 @@ func TestTestInitWhenUINotEnabled(t *testing.T) {
 	assert.IsType(t, new(view), layout.logsView)
 	assert.IsType(t, new(view), layout.forwardsView)
 	assert.IsType(t, new(view), layout.proxyView)
+	assert.IsType(t, new(view), layout.inspectorView)
 
 	assert.Nil(t, layout.statusView.GetView())
 	assert.Nil(t, layout.fullscreenView.GetView())
 	assert.Nil(t, layout.logsView.GetView())
 	assert.Nil(t, layout.forwardsView.GetView())
 	assert.Nil(t, layout.proxyView.GetView())
+	assert.Nil(t, layout.inspectorView.GetView())
 }
 
 func TestGetGui(t *testing.T) {
@@ -124,6 +158,24 @@ func TestGetProxyView(t *testing.T) {
 	assert.Equal(t, " Proxy ", result.GetTitle())
 }
 
+func TestGetInspectorView(t *testing.T) {
+	// Given
+	layout := NewLayout(true)
+	layout.gui.Close()
+
+	layout.SetInspectorEnabled(true)
+	layout.Init()
+
+	// When
+	result := layout.GetInspectorView()
+
+	// Then
+	assert.IsType(t, new(view), result)
+
+	assert.Equal(t, "inspector", result.GetName())
+	assert.Equal(t, " Inspector ", result.GetTitle())
+}
+
 func TestGetStatusView(t *testing.T) {
 	// Given
 	layout := NewLayout(true)